@@ -0,0 +1,48 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AnnotationPaused set to "true" on an organization or deployment stops
+	// the controllers from reconciling it. Its status is left untouched,
+	// except for the Paused condition. Removing the annotation resumes the
+	// reconciliation. A deleted object is reconciled regardless, so that its
+	// finalizer is removed.
+	AnnotationPaused = Group + "/paused"
+
+	// AnnotationReconcileRequest on an organization or deployment triggers an
+	// immediate reconcile and restarts its requeue schedule. The controller
+	// removes the annotation once the request is handled, its value is not
+	// interpreted. It is ignored while the object is paused.
+	AnnotationReconcileRequest = Group + "/reconcile-request"
+)
+
+// IsPaused returns true when the object carries the paused annotation.
+func IsPaused(o metav1.Object) bool {
+	return o.GetAnnotations()[AnnotationPaused] == "true"
+}
+
+// HasReconcileRequest returns true when the object carries a reconcile
+// request annotation.
+func HasReconcileRequest(o metav1.Object) bool {
+	_, ok := o.GetAnnotations()[AnnotationReconcileRequest]
+	return ok
+}
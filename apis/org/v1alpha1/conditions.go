@@ -28,6 +28,8 @@ import (
 const (
 	// A ConditionKindAllocationReady indicates whether the allocation is ready.
 	ConditionKindReady nddv1.ConditionKind = "Ready"
+	// A ConditionKindPaused indicates whether the reconciliation is paused.
+	ConditionKindPaused nddv1.ConditionKind = "Paused"
 )
 
 // ConditionReasons a package is or is not installed.
@@ -36,6 +38,8 @@ const (
 	ConditionReasonNotReady     nddv1.ConditionReason = "NotReady"
 	ConditionReasonAllocating   nddv1.ConditionReason = "Allocating"
 	ConditionReasonDeAllocating nddv1.ConditionReason = "DeAllocating"
+	ConditionReasonPaused       nddv1.ConditionReason = "PausedByAnnotation"
+	ConditionReasonUnpaused     nddv1.ConditionReason = "Reconciling"
 )
 
 // Ready indicates that the resource is ready.
@@ -57,3 +61,24 @@ func NotReady() nddv1.Condition {
 		Reason:             ConditionReasonNotReady,
 	}
 }
+
+// Paused indicates that the reconciliation of the resource is paused.
+func Paused() nddv1.Condition {
+	return nddv1.Condition{
+		Kind:               ConditionKindPaused,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ConditionReasonPaused,
+		Message:            "reconciliation paused by the " + AnnotationPaused + " annotation",
+	}
+}
+
+// Unpaused indicates that the resource is reconciled.
+func Unpaused() nddv1.Condition {
+	return nddv1.Condition{
+		Kind:               ConditionKindPaused,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ConditionReasonUnpaused,
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	"github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+const (
+	// errors
+	errUnexpectedHub = "unexpected conversion hub %T"
+)
+
+var (
+	_ conversion.Convertible = &Organization{}
+	_ conversion.Convertible = &Deployment{}
+)
+
+// ConvertTo converts the organization to the v1alpha2 hub.
+func (x *Organization) ConvertTo(h conversion.Hub) error {
+	dst, ok := h.(*v1alpha2.Organization)
+	if !ok {
+		return fmt.Errorf(errUnexpectedHub, h)
+	}
+	dst.ObjectMeta = x.ObjectMeta
+	dst.Spec.ResourceSpec = x.Spec.ResourceSpec
+	dst.Spec.Properties = v1alpha2.OrganizationProperties{
+		Description:               stringValue(x.Spec.Properties.Description),
+		Registers:                 registersTo(x.Spec.Properties.Register),
+		AddressAllocationStrategy: x.Spec.Properties.AddressAllocationStrategy,
+		ASN:                       int64Value(x.Spec.Properties.ASN),
+		DerivedTemplates:          x.Spec.Properties.DerivedTemplates,
+	}
+	if cm := x.Spec.Properties.ConfigMap; cm != nil {
+		dst.Spec.Properties.ConfigMap = &v1alpha2.ConfigMapPublication{Namespace: cm.Namespace}
+	}
+	dst.Status.ResourceStatus = x.Status.ResourceStatus
+	dst.Status.ObservedState = v1alpha2.ObservedState{}
+	if s := x.Status.Organization; s != nil {
+		dst.Status.ObservedState = observedStateTo(s.State, s.Register, s.AddressAllocationStrategy)
+		dst.Status.ObservedState.OrganizationID = s.OrganizationID
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha2 hub to the organization.
+func (x *Organization) ConvertFrom(h conversion.Hub) error {
+	src, ok := h.(*v1alpha2.Organization)
+	if !ok {
+		return fmt.Errorf(errUnexpectedHub, h)
+	}
+	x.ObjectMeta = src.ObjectMeta
+	x.Spec.ResourceSpec = src.Spec.ResourceSpec
+	x.Spec.Properties = OrganizationProperties{
+		Description:               stringPtr(src.Spec.Properties.Description),
+		Register:                  registersFrom(src.Spec.Properties.Registers),
+		AddressAllocationStrategy: src.Spec.Properties.AddressAllocationStrategy,
+		ASN:                       int64Ptr(src.Spec.Properties.ASN),
+		DerivedTemplates:          src.Spec.Properties.DerivedTemplates,
+	}
+	if cm := src.Spec.Properties.ConfigMap; cm != nil {
+		x.Spec.Properties.ConfigMap = &ConfigMapPublication{Namespace: cm.Namespace}
+	}
+	x.Status.ResourceStatus = src.Status.ResourceStatus
+	x.Status.Organization = nil
+	if o := src.Status.ObservedState; !observedStateEmpty(o) {
+		x.Status.Organization = &NddrOrganization{
+			Register:                  registersFrom(o.Registers),
+			AddressAllocationStrategy: o.AddressAllocationStrategy,
+			State:                     stateFrom(o),
+			OrganizationID:            o.OrganizationID,
+		}
+	}
+	return nil
+}
+
+// ConvertTo converts the deployment to the v1alpha2 hub.
+func (x *Deployment) ConvertTo(h conversion.Hub) error {
+	dst, ok := h.(*v1alpha2.Deployment)
+	if !ok {
+		return fmt.Errorf(errUnexpectedHub, h)
+	}
+	dst.ObjectMeta = x.ObjectMeta
+	dst.Spec.ResourceSpec = x.Spec.ResourceSpec
+	dst.Spec.Properties = v1alpha2.DeploymentProperties{
+		DeploymentClassName:       stringValue(x.Spec.Properties.DeploymentClassName),
+		AdminState:                v1alpha2.AdminState(stringValue(x.Spec.Properties.AdminState)),
+		Description:               stringValue(x.Spec.Properties.Description),
+		Region:                    stringValue(x.Spec.Properties.Region),
+		Kind:                      v1alpha2.DeploymentKind(stringValue(x.Spec.Properties.Kind)),
+		Registers:                 registersTo(x.Spec.Properties.Register),
+		AddressAllocationStrategy: x.Spec.Properties.AddressAllocationStrategy,
+		Attributes:                x.Spec.Properties.Attributes,
+	}
+	if ref := x.Spec.Properties.OrganizationRef; ref != nil {
+		dst.Spec.Properties.OrganizationRef = &v1alpha2.OrganizationRef{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+		}
+	}
+	dst.Status.ResourceStatus = x.Status.ResourceStatus
+	dst.Status.ObservedState = v1alpha2.ObservedState{}
+	if s := x.Status.Deployment; s != nil {
+		dst.Status.ObservedState = observedStateTo(s.State, s.Register, s.AddressAllocationStrategy)
+		if p := s.RegisterPolicy; p != nil {
+			dst.Status.ObservedState.RegisterPolicy = &v1alpha2.RegisterPolicyRuleRef{Policy: p.Policy, Rule: p.Rule}
+		}
+		if c := s.DeploymentClass; c != nil {
+			dst.Status.ObservedState.DeploymentClass = &v1alpha2.ResolvedDeploymentClass{
+				Name:       c.Name,
+				Generation: c.Generation,
+				AdminState: v1alpha2.AdminState(stringValue(c.AdminState)),
+				Kind:       v1alpha2.DeploymentKind(stringValue(c.Kind)),
+				Labels:     c.Labels,
+			}
+		}
+		dst.Status.ObservedState.CriticalRegisters = s.CriticalRegisters
+		dst.Status.ObservedState.OrganizationID = s.OrganizationID
+		dst.Status.ObservedState.DeploymentID = s.DeploymentID
+		dst.Status.ObservedState.Derived = s.Derived
+		if cm := s.ConfigMap; cm != nil {
+			dst.Status.ObservedState.ConfigMap = &v1alpha2.ConfigMapRef{Namespace: cm.Namespace, Name: cm.Name}
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha2 hub to the deployment.
+func (x *Deployment) ConvertFrom(h conversion.Hub) error {
+	src, ok := h.(*v1alpha2.Deployment)
+	if !ok {
+		return fmt.Errorf(errUnexpectedHub, h)
+	}
+	x.ObjectMeta = src.ObjectMeta
+	x.Spec.ResourceSpec = src.Spec.ResourceSpec
+	x.Spec.Properties = DeploymentProperties{
+		DeploymentClassName:       stringPtr(src.Spec.Properties.DeploymentClassName),
+		AdminState:                stringPtr(string(src.Spec.Properties.AdminState)),
+		Description:               stringPtr(src.Spec.Properties.Description),
+		Region:                    stringPtr(src.Spec.Properties.Region),
+		Kind:                      stringPtr(string(src.Spec.Properties.Kind)),
+		Register:                  registersFrom(src.Spec.Properties.Registers),
+		AddressAllocationStrategy: src.Spec.Properties.AddressAllocationStrategy,
+		Attributes:                src.Spec.Properties.Attributes,
+	}
+	if ref := src.Spec.Properties.OrganizationRef; ref != nil {
+		x.Spec.Properties.OrganizationRef = &OrganizationReference{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+		}
+	}
+	x.Status.ResourceStatus = src.Status.ResourceStatus
+	x.Status.Deployment = nil
+	if o := src.Status.ObservedState; !observedStateEmpty(o) {
+		x.Status.Deployment = &NddrOrgDeployment{
+			Register:                  registersFrom(o.Registers),
+			AddressAllocationStrategy: o.AddressAllocationStrategy,
+			State:                     stateFrom(o),
+		}
+		if p := o.RegisterPolicy; p != nil {
+			x.Status.Deployment.RegisterPolicy = &RegisterPolicyRuleReference{Policy: p.Policy, Rule: p.Rule}
+		}
+		if c := o.DeploymentClass; c != nil {
+			x.Status.Deployment.DeploymentClass = &ResolvedDeploymentClass{
+				Name:       c.Name,
+				Generation: c.Generation,
+				AdminState: stringPtr(string(c.AdminState)),
+				Kind:       stringPtr(string(c.Kind)),
+				Labels:     c.Labels,
+			}
+		}
+		x.Status.Deployment.CriticalRegisters = o.CriticalRegisters
+		x.Status.Deployment.OrganizationID = o.OrganizationID
+		x.Status.Deployment.DeploymentID = o.DeploymentID
+		x.Status.Deployment.Derived = o.Derived
+		if cm := o.ConfigMap; cm != nil {
+			x.Status.Deployment.ConfigMap = &ConfigMapReference{Namespace: cm.Namespace, Name: cm.Name}
+		}
+	}
+	return nil
+}
+
+func registersTo(r []*nddov1.Register) []v1alpha2.Register {
+	if len(r) == 0 {
+		return nil
+	}
+	registers := make([]v1alpha2.Register, 0, len(r))
+	for _, register := range r {
+		if register == nil {
+			continue
+		}
+		registers = append(registers, v1alpha2.Register{
+			Kind: stringValue(register.Kind),
+			Name: stringValue(register.Name),
+		})
+	}
+	return registers
+}
+
+func registersFrom(r []v1alpha2.Register) []*nddov1.Register {
+	if len(r) == 0 {
+		return nil
+	}
+	registers := make([]*nddov1.Register, 0, len(r))
+	for _, register := range r {
+		registers = append(registers, &nddov1.Register{
+			Kind: utils.StringPtr(register.Kind),
+			Name: utils.StringPtr(register.Name),
+		})
+	}
+	return registers
+}
+
+func observedStateTo(s *NddrOrgDeploymentState, r []*nddov1.Register, aas *nddov1.AddressAllocationStrategy) v1alpha2.ObservedState {
+	o := v1alpha2.ObservedState{
+		Registers:                 registersTo(r),
+		AddressAllocationStrategy: aas,
+	}
+	if s != nil {
+		o.State = v1alpha2.State(stringValue(s.Status))
+		o.Reason = stringValue(s.Reason)
+	}
+	return o
+}
+
+func observedStateEmpty(o v1alpha2.ObservedState) bool {
+	return o.State == "" && o.Reason == "" && len(o.Registers) == 0 &&
+		o.AddressAllocationStrategy == nil && o.RegisterPolicy == nil && o.DeploymentClass == nil &&
+		len(o.CriticalRegisters) == 0 && o.OrganizationID == nil && o.DeploymentID == nil &&
+		len(o.Derived) == 0 && o.ConfigMap == nil
+}
+
+func stateFrom(o v1alpha2.ObservedState) *NddrOrgDeploymentState {
+	return &NddrOrgDeploymentState{
+		Status: stringPtr(string(o.State)),
+		Reason: stringPtr(o.Reason),
+	}
+}
+
+// stringPtr returns nil for an empty string, v1alpha1 omits unset strings.
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int64Ptr(i int64) *int64 {
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
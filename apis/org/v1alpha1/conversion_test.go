@@ -0,0 +1,171 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	"github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testRegister() []*nddov1.Register {
+	return []*nddov1.Register{
+		{Kind: utils.StringPtr("as"), Name: utils.StringPtr("nokia-as")},
+		{Kind: utils.StringPtr("ipam"), Name: utils.StringPtr("nokia-ipam")},
+	}
+}
+
+func TestOrganizationConversionRoundTrip(t *testing.T) {
+	cases := map[string]*Organization{
+		"Empty": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"},
+		},
+		"Full": {
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "nokia",
+				Annotations: map[string]string{AnnotationPaused: "true"},
+			},
+			Spec: OrganizationSpec{
+				ResourceSpec: nddv1.ResourceSpec{TargetReference: &nddv1.Reference{Name: "target"}},
+				Properties: OrganizationProperties{
+					Description:      utils.StringPtr("nokia organization"),
+					Register:         testRegister(),
+					ASN:              int64Ptr(65000),
+					DerivedTemplates: map[string]string{"rt-base": "{{.OrgASN}}:{{.DeploymentID}}"},
+					ConfigMap:        &ConfigMapPublication{Namespace: "published"},
+				},
+			},
+			Status: OrganizationStatus{
+				Organization: &NddrOrganization{
+					Register:       testRegister(),
+					State:          &NddrOrgDeploymentState{Status: utils.StringPtr("up")},
+					OrganizationID: idPtr(3),
+				},
+			},
+		},
+	}
+
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			hub := &v1alpha2.Organization{}
+			if err := want.DeepCopy().ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo(): %v", err)
+			}
+			got := &Organization{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom(): %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("round trip: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeploymentConversionRoundTrip(t *testing.T) {
+	cases := map[string]*Deployment{
+		"Empty": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"},
+		},
+		"Full": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"},
+			Spec: DeploymentSpec{
+				Properties: DeploymentProperties{
+					OrganizationRef:     &OrganizationReference{Name: "nokia", Namespace: "orgs"},
+					DeploymentClassName: utils.StringPtr("standard-dc"),
+					AdminState:          utils.StringPtr("disable"),
+					Description:         utils.StringPtr("dc1"),
+					Region:              utils.StringPtr("eu-west"),
+					Kind:                utils.StringPtr("dc"),
+					Register:            testRegister(),
+					Attributes:          &apiextensionsv1.JSON{Raw: []byte(`{"fabric-size":8}`)},
+				},
+			},
+			Status: DeploymentStatus{
+				Deployment: &NddrOrgDeployment{
+					State: &NddrOrgDeploymentState{
+						Status: utils.StringPtr("down"),
+						Reason: utils.StringPtr("admin state disabled"),
+					},
+					RegisterPolicy: &RegisterPolicyRuleReference{Policy: "wan", Rule: "emea"},
+					DeploymentClass: &ResolvedDeploymentClass{
+						Name:       "standard-dc",
+						Generation: 3,
+						Kind:       utils.StringPtr("dc"),
+						Labels:     map[string]string{"tier": "core"},
+					},
+					CriticalRegisters: []string{"esi"},
+					OrganizationID:    idPtr(3),
+					DeploymentID:      idPtr(7),
+					Derived:           map[string]string{"rt-base": "65000:7"},
+					ConfigMap:         &ConfigMapReference{Namespace: "default", Name: "nokia.dc1"},
+				},
+			},
+		},
+	}
+
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			hub := &v1alpha2.Deployment{}
+			if err := want.DeepCopy().ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo(): %v", err)
+			}
+			got := &Deployment{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom(): %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("round trip: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeploymentConversionKeepsOrganizationRef(t *testing.T) {
+	want := &v1alpha2.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dc1"},
+		Spec: v1alpha2.DeploymentSpec{
+			Properties: v1alpha2.DeploymentProperties{
+				OrganizationRef: &v1alpha2.OrganizationRef{Name: "nokia", Namespace: "orgs"},
+				AdminState:      v1alpha2.AdminStateEnable,
+				Kind:            v1alpha2.DeploymentKindDC,
+			},
+		},
+	}
+
+	spoke := &Deployment{}
+	if err := spoke.ConvertFrom(want.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom(): %v", err)
+	}
+	if got := spoke.GetOrganizationName(); got != "nokia" {
+		t.Fatalf("ConvertFrom(): want organization nokia, got %q", got)
+	}
+	got := &v1alpha2.Deployment{}
+	if err := spoke.ConvertTo(got); err != nil {
+		t.Fatalf("ConvertTo(): %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("round trip: -want, +got:\n%s", diff)
+	}
+}
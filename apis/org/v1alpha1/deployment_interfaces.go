@@ -17,16 +17,26 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/yndd/app-runtime/pkg/odns"
 	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
 	"github.com/yndd/ndd-runtime/pkg/resource"
 	"github.com/yndd/ndd-runtime/pkg/utils"
 	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// errors
+	errEmptyOrganizationRef    = "organization reference without a name"
+	errOrganizationRefMismatch = "organization reference %q does not match the organization %q of the deployment name"
+)
+
 var _ DpList = &DeploymentList{}
 
 // +k8s:deepcopy-gen=false
@@ -69,22 +79,42 @@ type Dp interface {
 	SetRootPaths(rootPaths []string)
 
 	GetOrganizationName() string
+	GetOrganizationNamespace() string
+	ValidateOrganizationRef() error
 	GetDeploymentName() string
+	GetDeploymentClassName() string
+	GetDeploymentLabels() map[string]string
 	GetAdminState() string
 	GetDescription() string
 	GetKind() string
 	GetRegion() string
 	GetRegister() map[string]string
 	GetAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
+	GetAttributes() *apiextensionsv1.JSON
 	InitializeResource() error
 
 	SetStatus(string)
 	SetReason(string)
 	GetStatus() string
+	GetReason() string
 	GetStateRegister() map[string]string
 	SetStateRegister(map[string]string)
 	GetStateAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
 	SetStateAddressAllocationStrategy(*nddov1.AddressAllocationStrategy)
+	GetStateRegisterPolicy() *RegisterPolicyRuleReference
+	SetStateRegisterPolicy(*RegisterPolicyRuleReference)
+	GetStateDeploymentClass() *ResolvedDeploymentClass
+	SetStateDeploymentClass(*ResolvedDeploymentClass)
+	GetStateCriticalRegisters() []string
+	SetStateCriticalRegisters([]string)
+	GetStateOrganizationID() int64
+	SetStateOrganizationID(int64)
+	GetStateDeploymentID() int64
+	SetStateDeploymentID(int64)
+	GetStateDerived() map[string]string
+	SetStateDerived(map[string]string)
+	GetStateConfigMap() *ConfigMapReference
+	SetStateConfigMap(*ConfigMapReference)
 }
 
 // GetCondition of this Network Node.
@@ -133,19 +163,85 @@ func (x *Deployment) SetRootPaths(rootPaths []string) {
 	x.Status.RootPaths = rootPaths
 }
 
+// GetOrganizationName returns the name of the referenced organization, or the
+// odns prefix of the deployment name when no organization is referenced.
 func (x *Deployment) GetOrganizationName() string {
+	if x.Spec.Properties.OrganizationRef != nil {
+		return x.Spec.Properties.OrganizationRef.Name
+	}
 	return odns.Name2Odns(x.GetName()).GetOrganization()
 }
 
+// GetOrganizationNamespace returns the namespace of the referenced
+// organization, it is empty when the reference does not set one.
+func (x *Deployment) GetOrganizationNamespace() string {
+	if x.Spec.Properties.OrganizationRef != nil {
+		return x.Spec.Properties.OrganizationRef.Namespace
+	}
+	return ""
+}
+
+// ValidateOrganizationRef returns an error when the organization reference
+// contradicts the odns prefix of the deployment name.
+func (x *Deployment) ValidateOrganizationRef() error {
+	ref := x.Spec.Properties.OrganizationRef
+	if ref == nil {
+		return nil
+	}
+	if ref.Name == "" {
+		return errors.New(errEmptyOrganizationRef)
+	}
+	if !strings.Contains(x.GetName(), ".") {
+		// the name has no odns prefix
+		return nil
+	}
+	if org := odns.Name2Odns(x.GetName()).GetOrganization(); org != ref.Name {
+		return fmt.Errorf(errOrganizationRefMismatch, ref.Name, org)
+	}
+	return nil
+}
+
+// GetDeploymentName returns the deployment segment of the odns name, a
+// deployment that references its organization may use a plain name.
 func (x *Deployment) GetDeploymentName() string {
+	if x.Spec.Properties.OrganizationRef != nil && !strings.Contains(x.GetName(), ".") {
+		return x.GetName()
+	}
 	return odns.Name2Odns(x.GetName()).GetDeployment()
 }
 
-func (x *Deployment) GetAdminState() string {
-	if reflect.ValueOf(x.Spec.Properties.AdminState).IsZero() {
+func (x *Deployment) GetDeploymentClassName() string {
+	if reflect.ValueOf(x.Spec.Properties.DeploymentClassName).IsZero() {
 		return ""
 	}
-	return *x.Spec.Properties.AdminState
+	return *x.Spec.Properties.DeploymentClassName
+}
+
+// GetDeploymentLabels returns the labels of the deployment on top of the
+// labels of its resolved deployment class.
+func (x *Deployment) GetDeploymentLabels() map[string]string {
+	l := make(map[string]string)
+	if c := x.GetStateDeploymentClass(); c != nil {
+		for k, v := range c.Labels {
+			l[k] = v
+		}
+	}
+	for k, v := range x.GetLabels() {
+		l[k] = v
+	}
+	return l
+}
+
+// GetAdminState returns the admin state of the deployment, or of its
+// resolved deployment class when the deployment does not set one.
+func (x *Deployment) GetAdminState() string {
+	if !reflect.ValueOf(x.Spec.Properties.AdminState).IsZero() {
+		return *x.Spec.Properties.AdminState
+	}
+	if c := x.GetStateDeploymentClass(); c != nil && c.AdminState != nil {
+		return *c.AdminState
+	}
+	return ""
 }
 
 func (x *Deployment) GetDescription() string {
@@ -155,11 +251,16 @@ func (x *Deployment) GetDescription() string {
 	return *x.Spec.Properties.Description
 }
 
+// GetKind returns the kind of the deployment, or of its resolved deployment
+// class when the deployment does not set one.
 func (x *Deployment) GetKind() string {
-	if reflect.ValueOf(x.Spec.Properties.Kind).IsZero() {
-		return ""
+	if !reflect.ValueOf(x.Spec.Properties.Kind).IsZero() {
+		return *x.Spec.Properties.Kind
+	}
+	if c := x.GetStateDeploymentClass(); c != nil && c.Kind != nil {
+		return *c.Kind
 	}
-	return *x.Spec.Properties.Kind
+	return ""
 }
 
 func (x *Deployment) GetRegion() string {
@@ -189,6 +290,12 @@ func (x *Deployment) GetAddressAllocationStrategy() *nddov1.AddressAllocationStr
 	return x.Spec.Properties.AddressAllocationStrategy
 }
 
+// GetAttributes returns the kind specific attributes of the deployment, nil
+// when the deployment has none.
+func (x *Deployment) GetAttributes() *apiextensionsv1.JSON {
+	return x.Spec.Properties.Attributes
+}
+
 func (x *Deployment) InitializeResource() error {
 	if x.Status.Deployment != nil {
 		// resource was already initialiazed
@@ -222,6 +329,13 @@ func (x *Deployment) GetStatus() string {
 	return "unknown"
 }
 
+func (x *Deployment) GetReason() string {
+	if x.Status.Deployment != nil && x.Status.Deployment.State != nil && x.Status.Deployment.State.Reason != nil {
+		return *x.Status.Deployment.State.Reason
+	}
+	return ""
+}
+
 func (x *Deployment) GetStateRegister() map[string]string {
 	r := make(map[string]string)
 	if x.Status.Deployment != nil && x.Status.Deployment.State != nil && x.Status.Deployment.State.Status != nil {
@@ -235,13 +349,7 @@ func (x *Deployment) GetStateRegister() map[string]string {
 }
 
 func (x *Deployment) SetStateRegister(r map[string]string) {
-	x.Status.Deployment.Register = make([]*nddov1.Register, 0, len(r))
-	for kind, name := range r {
-		x.Status.Deployment.Register = append(x.Status.Deployment.Register, &nddov1.Register{
-			Kind: utils.StringPtr(kind),
-			Name: utils.StringPtr(name),
-		})
-	}
+	x.Status.Deployment.Register = registerList(r)
 }
 
 func (x *Deployment) GetStateAddressAllocationStrategy() *nddov1.AddressAllocationStrategy {
@@ -254,3 +362,84 @@ func (x *Deployment) GetStateAddressAllocationStrategy() *nddov1.AddressAllocati
 func (x *Deployment) SetStateAddressAllocationStrategy(a *nddov1.AddressAllocationStrategy) {
 	x.Status.Deployment.AddressAllocationStrategy = a
 }
+
+func (x *Deployment) GetStateRegisterPolicy() *RegisterPolicyRuleReference {
+	if x.Status.Deployment != nil {
+		return x.Status.Deployment.RegisterPolicy
+	}
+	return nil
+}
+
+func (x *Deployment) SetStateRegisterPolicy(r *RegisterPolicyRuleReference) {
+	x.Status.Deployment.RegisterPolicy = r
+}
+
+func (x *Deployment) GetStateDeploymentClass() *ResolvedDeploymentClass {
+	if x.Status.Deployment != nil {
+		return x.Status.Deployment.DeploymentClass
+	}
+	return nil
+}
+
+func (x *Deployment) SetStateDeploymentClass(c *ResolvedDeploymentClass) {
+	x.Status.Deployment.DeploymentClass = c
+}
+
+// GetStateCriticalRegisters returns the critical registers of the kind of the
+// deployment, the registers that are always critical are not included.
+func (x *Deployment) GetStateCriticalRegisters() []string {
+	if x.Status.Deployment != nil {
+		return x.Status.Deployment.CriticalRegisters
+	}
+	return nil
+}
+
+func (x *Deployment) SetStateCriticalRegisters(r []string) {
+	x.Status.Deployment.CriticalRegisters = r
+}
+
+// GetStateOrganizationID returns 0 when the organization has no id yet.
+func (x *Deployment) GetStateOrganizationID() int64 {
+	if x.Status.Deployment != nil {
+		return idValue(x.Status.Deployment.OrganizationID)
+	}
+	return 0
+}
+
+func (x *Deployment) SetStateOrganizationID(id int64) {
+	x.Status.Deployment.OrganizationID = idPtr(id)
+}
+
+// GetStateDeploymentID returns 0 when no id is allocated yet.
+func (x *Deployment) GetStateDeploymentID() int64 {
+	if x.Status.Deployment != nil {
+		return idValue(x.Status.Deployment.DeploymentID)
+	}
+	return 0
+}
+
+func (x *Deployment) SetStateDeploymentID(id int64) {
+	x.Status.Deployment.DeploymentID = idPtr(id)
+}
+
+func (x *Deployment) GetStateDerived() map[string]string {
+	if x.Status.Deployment != nil {
+		return x.Status.Deployment.Derived
+	}
+	return nil
+}
+
+func (x *Deployment) SetStateDerived(d map[string]string) {
+	x.Status.Deployment.Derived = d
+}
+
+func (x *Deployment) GetStateConfigMap() *ConfigMapReference {
+	if x.Status.Deployment != nil {
+		return x.Status.Deployment.ConfigMap
+	}
+	return nil
+}
+
+func (x *Deployment) SetStateConfigMap(r *ConfigMapReference) {
+	x.Status.Deployment.ConfigMap = r
+}
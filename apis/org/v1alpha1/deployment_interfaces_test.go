@@ -0,0 +1,235 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentOdnsNames(t *testing.T) {
+	cases := map[string]struct {
+		name           string
+		wantOrg        string
+		wantDeployment string
+	}{
+		"OrganizationAndDeployment": {
+			name:           "nokia.dc1",
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"NoDots": {
+			name:    "nokia",
+			wantOrg: "nokia",
+		},
+		"AvailabilityZoneIsIgnored": {
+			name:           "nokia.dc1.az1",
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"ExtraSegmentsAreIgnored": {
+			name:           "nokia.dc1.az1.leaf1",
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"TrailingDot": {
+			name:    "nokia.",
+			wantOrg: "nokia",
+		},
+		"LeadingDot": {
+			name:           ".dc1",
+			wantDeployment: "dc1",
+		},
+		"Empty": {
+			name: "",
+		},
+		"Unicode": {
+			name:           "nökia.dc-ü",
+			wantOrg:        "nökia",
+			wantDeployment: "dc-ü",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := &Deployment{ObjectMeta: metav1.ObjectMeta{Name: tc.name}}
+			if got := cr.GetOrganizationName(); got != tc.wantOrg {
+				t.Errorf("GetOrganizationName() of %q: want %q, got %q", tc.name, tc.wantOrg, got)
+			}
+			if got := cr.GetDeploymentName(); got != tc.wantDeployment {
+				t.Errorf("GetDeploymentName() of %q: want %q, got %q", tc.name, tc.wantDeployment, got)
+			}
+		})
+	}
+}
+
+func FuzzDeploymentOdnsNames(f *testing.F) {
+	for _, seed := range []string{"nokia.dc1", "nokia", "nokia.dc1.az1.leaf1", "", ".", "..", "nökia.dc-ü"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		cr := &Deployment{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		org, dep := cr.GetOrganizationName(), cr.GetDeploymentName()
+
+		// the names are the first two segments of the name
+		segments := strings.Split(name, ".")
+		if org != segments[0] {
+			t.Fatalf("GetOrganizationName() of %q: want %q, got %q", name, segments[0], org)
+		}
+		wantDep := ""
+		if len(segments) > 1 {
+			wantDep = segments[1]
+		}
+		if dep != wantDep {
+			t.Fatalf("GetDeploymentName() of %q: want %q, got %q", name, wantDep, dep)
+		}
+	})
+}
+
+func TestDeploymentOrganizationRef(t *testing.T) {
+	cases := map[string]struct {
+		name           string
+		ref            *OrganizationReference
+		wantOrg        string
+		wantNamespace  string
+		wantDeployment string
+		wantErr        bool
+	}{
+		"NoReference": {
+			name:           "nokia.dc1",
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"PlainName": {
+			name:           "dc1",
+			ref:            &OrganizationReference{Name: "nokia"},
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"OtherNamespace": {
+			name:           "dc1",
+			ref:            &OrganizationReference{Name: "nokia", Namespace: "orgs"},
+			wantOrg:        "nokia",
+			wantNamespace:  "orgs",
+			wantDeployment: "dc1",
+		},
+		"MatchingPrefix": {
+			name:           "nokia.dc1",
+			ref:            &OrganizationReference{Name: "nokia"},
+			wantOrg:        "nokia",
+			wantDeployment: "dc1",
+		},
+		"MismatchingPrefix": {
+			name:           "nokia.dc1",
+			ref:            &OrganizationReference{Name: "acme"},
+			wantOrg:        "acme",
+			wantDeployment: "dc1",
+			wantErr:        true,
+		},
+		"EmptyName": {
+			name:           "dc1",
+			ref:            &OrganizationReference{},
+			wantDeployment: "dc1",
+			wantErr:        true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := &Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name},
+				Spec:       DeploymentSpec{Properties: DeploymentProperties{OrganizationRef: tc.ref}},
+			}
+			if err := cr.ValidateOrganizationRef(); (err != nil) != tc.wantErr {
+				t.Errorf("ValidateOrganizationRef(): want error %t, got %v", tc.wantErr, err)
+			}
+			if got := cr.GetOrganizationName(); got != tc.wantOrg {
+				t.Errorf("GetOrganizationName(): want %q, got %q", tc.wantOrg, got)
+			}
+			if got := cr.GetOrganizationNamespace(); got != tc.wantNamespace {
+				t.Errorf("GetOrganizationNamespace(): want %q, got %q", tc.wantNamespace, got)
+			}
+			if got := cr.GetDeploymentName(); got != tc.wantDeployment {
+				t.Errorf("GetDeploymentName(): want %q, got %q", tc.wantDeployment, got)
+			}
+		})
+	}
+}
+
+func TestDeploymentClassDefaults(t *testing.T) {
+	class := &DeploymentClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard-dc", Generation: 2},
+		Spec: DeploymentClassSpec{Properties: DeploymentClassProperties{
+			AdminState: utils.StringPtr("disable"),
+			Kind:       utils.StringPtr("wan"),
+			Labels:     map[string]string{"tier": "edge", "site": "class"},
+		}},
+	}
+
+	cases := map[string]struct {
+		props          DeploymentProperties
+		labels         map[string]string
+		wantAdminState string
+		wantKind       string
+		wantLabels     map[string]string
+	}{
+		"ClassDefaults": {
+			wantAdminState: "disable",
+			wantKind:       "wan",
+			wantLabels:     map[string]string{"tier": "edge", "site": "class"},
+		},
+		"DeploymentFieldsWin": {
+			props:          DeploymentProperties{AdminState: utils.StringPtr("enable"), Kind: utils.StringPtr("dc")},
+			labels:         map[string]string{"site": "dc1"},
+			wantAdminState: "enable",
+			wantKind:       "dc",
+			wantLabels:     map[string]string{"tier": "edge", "site": "dc1"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := &Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "nokia.dc1", Labels: tc.labels},
+				Spec:       DeploymentSpec{Properties: tc.props},
+			}
+			_ = cr.InitializeResource()
+			cr.SetStateDeploymentClass(class.Resolve())
+
+			if got := cr.GetAdminState(); got != tc.wantAdminState {
+				t.Errorf("GetAdminState(): want %q, got %q", tc.wantAdminState, got)
+			}
+			if got := cr.GetKind(); got != tc.wantKind {
+				t.Errorf("GetKind(): want %q, got %q", tc.wantKind, got)
+			}
+			if got := cr.GetDeploymentLabels(); !reflect.DeepEqual(got, tc.wantLabels) {
+				t.Errorf("GetDeploymentLabels(): want %v, got %v", tc.wantLabels, got)
+			}
+		})
+	}
+
+	// the resolved class does not share the labels of the class
+	r := class.Resolve()
+	r.Labels["tier"] = "changed"
+	if class.Spec.Properties.Labels["tier"] != "edge" {
+		t.Errorf("Resolve(): the labels of the class were modified")
+	}
+}
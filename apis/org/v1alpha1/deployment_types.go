@@ -21,6 +21,7 @@ import (
 
 	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
 	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -29,6 +30,47 @@ type NddrOrgDeployment struct {
 	Register                  []*nddov1.Register                `json:"register,omitempty"`
 	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
 	State                     *NddrOrgDeploymentState           `json:"state,omitempty"`
+	// RegisterPolicy is the register policy rule that matched the deployment
+	RegisterPolicy *RegisterPolicyRuleReference `json:"register-policy,omitempty"`
+	// DeploymentClass is the class the defaults of the deployment were
+	// resolved from
+	DeploymentClass *ResolvedDeploymentClass `json:"deployment-class,omitempty"`
+	// CriticalRegisters are the critical registers of the kind of the
+	// deployment, in addition to the registers that are always critical
+	CriticalRegisters []string `json:"critical-registers,omitempty"`
+	// OrganizationID is the id of the organization of the deployment
+	OrganizationID *int64 `json:"organization-id,omitempty"`
+	// DeploymentID is unique in the organization and never changes while
+	// the deployment exists
+	DeploymentID *int64 `json:"deployment-id,omitempty"`
+	// Derived are the identifiers rendered from the derived templates of the
+	// organization
+	Derived map[string]string `json:"derived,omitempty"`
+	// ConfigMap is the ConfigMap the deployment is published in
+	ConfigMap *ConfigMapReference `json:"config-map,omitempty"`
+}
+
+// ConfigMapReference references the ConfigMap of a deployment.
+type ConfigMapReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// ResolvedDeploymentClass is the deployment class a deployment was
+// reconciled with.
+type ResolvedDeploymentClass struct {
+	Name string `json:"name"`
+	// Generation of the class
+	Generation int64             `json:"generation,omitempty"`
+	AdminState *string           `json:"admin-state,omitempty"`
+	Kind       *string           `json:"kind,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// RegisterPolicyRuleReference references a rule of a register policy.
+type RegisterPolicyRuleReference struct {
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
 }
 
 type NddrOrgDeploymentState struct {
@@ -36,10 +78,27 @@ type NddrOrgDeploymentState struct {
 	Status *string `json:"status,omitempty"`
 }
 
+// OrganizationReference references the organization of a deployment.
+type OrganizationReference struct {
+	// Name of the organization
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the organization, the namespace of the deployment when
+	// empty
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // Deployment struct
 type DeploymentProperties struct {
+	// OrganizationRef references the organization of the deployment, when
+	// not set the organization is the odns prefix of the deployment name
+	OrganizationRef *OrganizationReference `json:"organization-ref,omitempty"`
+	// DeploymentClassName references the deployment class in the namespace
+	// of the deployment, the class provides the defaults of unset fields
+	DeploymentClassName *string `json:"deployment-class-name,omitempty"`
+	// AdminState defaults to the admin state of the class, a deployment
+	// without either is enabled
 	// +kubebuilder:validation:Enum=`disable`;`enable`
-	// +kubebuilder:default:="enable"
 	AdminState *string `json:"admin-state,omitempty"`
 	// kubebuilder:validation:MinLength=1
 	// kubebuilder:validation:MaxLength=255
@@ -47,11 +106,17 @@ type DeploymentProperties struct {
 	// +kubebuilder:validation:Pattern="[A-Za-z0-9 !@#$^&()|+=`~.,'/_:;?-]*"
 	Description *string `json:"description,omitempty"`
 	Region      *string `json:"region,omitempty"`
-	// +kubebuilder:validation:Enum=`dc`;`wan`
-	// +kubebuilder:default:="dc"
+	// Kind defaults to the kind of the class, kinds other than dc and wan
+	// need a DeploymentKindDefinition
 	Kind                      *string                           `json:"kind,omitempty"`
 	Register                  []*nddov1.Register                `json:"register,omitempty"`
 	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+	// Attributes are the kind specific attributes of the deployment, they
+	// are validated against the schema of the kind
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	Attributes *apiextensionsv1.JSON `json:"attributes,omitempty"`
 }
 
 // A DeploymentSpec defines the desired state of a Deployment.
@@ -73,12 +138,14 @@ type DeploymentStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="SYNC",type="string",JSONPath=".status.conditions[?(@.kind=='Synced')].status"
 // +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.conditions[?(@.kind=='Ready')].status"
+// +kubebuilder:printcolumn:name="ID",type="integer",JSONPath=".status.deployment.deployment-id"
 // +kubebuilder:printcolumn:name="IPAM",type="string",JSONPath=".status.deployment.register[?(@.kind=='ipam')].name"
 // +kubebuilder:printcolumn:name="NI",type="string",JSONPath=".status.deployment.register[?(@.kind=='ni')].name"
 // +kubebuilder:printcolumn:name="AS",type="string",JSONPath=".status.deployment.register[?(@.kind=='as')].name"
 // +kubebuilder:printcolumn:name="ESI",type="string",JSONPath=".status.deployment.register[?(@.kind=='esi')].name"
 // +kubebuilder:printcolumn:name="VLAN",type="string",JSONPath=".status.deployment.register[?(@.kind=='vlan')].name"
 // +kubebuilder:printcolumn:name="RT",type="string",JSONPath=".status.deployment.register[?(@.kind=='rt')].name"
+// +kubebuilder:printcolumn:name="POLICY",type="string",JSONPath=".status.deployment.register-policy.rule",priority=1
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 type Deployment struct {
 	metav1.TypeMeta   `json:",inline"`
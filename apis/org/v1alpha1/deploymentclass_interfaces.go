@@ -0,0 +1,85 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	"github.com/yndd/ndd-runtime/pkg/resource"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+)
+
+var _ Dc = &DeploymentClass{}
+
+// +k8s:deepcopy-gen=false
+type Dc interface {
+	resource.Object
+
+	GetAdminState() string
+	GetKind() string
+	GetRegister() map[string]string
+	GetAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
+	GetDefaultLabels() map[string]string
+	Resolve() *ResolvedDeploymentClass
+}
+
+func (x *DeploymentClass) GetAdminState() string {
+	if reflect.ValueOf(x.Spec.Properties.AdminState).IsZero() {
+		return ""
+	}
+	return *x.Spec.Properties.AdminState
+}
+
+func (x *DeploymentClass) GetKind() string {
+	if reflect.ValueOf(x.Spec.Properties.Kind).IsZero() {
+		return ""
+	}
+	return *x.Spec.Properties.Kind
+}
+
+func (x *DeploymentClass) GetRegister() map[string]string {
+	s := make(map[string]string)
+	for _, register := range x.Spec.Properties.Register {
+		for kind, name := range register.GetRegister() {
+			s[kind] = name
+		}
+	}
+	return s
+}
+
+// GetAddressAllocationStrategy returns nil when the class does not set a
+// strategy.
+func (x *DeploymentClass) GetAddressAllocationStrategy() *nddov1.AddressAllocationStrategy {
+	return x.Spec.Properties.AddressAllocationStrategy
+}
+
+func (x *DeploymentClass) GetDefaultLabels() map[string]string {
+	return x.Spec.Properties.Labels
+}
+
+// Resolve returns the class as reported in the status of its deployments.
+func (x *DeploymentClass) Resolve() *ResolvedDeploymentClass {
+	r := &ResolvedDeploymentClass{
+		Name:       x.GetName(),
+		Generation: x.GetGeneration(),
+		AdminState: x.Spec.Properties.AdminState,
+		Kind:       x.Spec.Properties.Kind,
+		Labels:     x.Spec.Properties.Labels,
+	}
+	// the status must not share the pointers of the class
+	return r.DeepCopy()
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DeploymentClass struct
+type DeploymentClassProperties struct {
+	// kubebuilder:validation:MaxLength=255
+	Description *string `json:"description,omitempty"`
+	// +kubebuilder:validation:Enum=`disable`;`enable`
+	AdminState                *string                           `json:"admin-state,omitempty"`
+	Kind                      *string                           `json:"kind,omitempty"`
+	Register                  []*nddov1.Register                `json:"register,omitempty"`
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+	// Labels are added to the labels of the deployments of the class when
+	// register policies are matched, labels of the deployment take
+	// precedence
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// A DeploymentClassSpec defines the desired state of a DeploymentClass.
+type DeploymentClassSpec struct {
+	Properties DeploymentClassProperties `json:"properties,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentClass holds the defaults of the deployments that reference it,
+// fields set on the deployment take precedence.
+// +kubebuilder:printcolumn:name="KIND",type="string",JSONPath=".spec.properties.kind"
+// +kubebuilder:printcolumn:name="ADMIN",type="string",JSONPath=".spec.properties.admin-state"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type DeploymentClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentClassList contains a list of DeploymentClasses
+type DeploymentClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentClass{}, &DeploymentClassList{})
+}
+
+// DeploymentClass type metadata.
+var (
+	DeploymentClassKindKind         = reflect.TypeOf(DeploymentClass{}).Name()
+	DeploymentClassGroupKind        = schema.GroupKind{Group: Group, Kind: DeploymentClassKindKind}.String()
+	DeploymentClassKindAPIVersion   = DeploymentClassKindKind + "." + GroupVersion.String()
+	DeploymentClassGroupVersionKind = GroupVersion.WithKind(DeploymentClassKindKind)
+)
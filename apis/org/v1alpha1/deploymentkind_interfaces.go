@@ -0,0 +1,70 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/yndd/ndd-runtime/pkg/resource"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// DeploymentKindDC and DeploymentKindWAN are built in, deployments of
+	// these kinds do not need a DeploymentKindDefinition.
+	DeploymentKindDC  = "dc"
+	DeploymentKindWAN = "wan"
+)
+
+// IsBuiltinDeploymentKind returns true for the kinds that are valid without
+// a DeploymentKindDefinition, including the empty kind.
+func IsBuiltinDeploymentKind(kind string) bool {
+	switch kind {
+	case "", DeploymentKindDC, DeploymentKindWAN:
+		return true
+	}
+	return false
+}
+
+var _ Dkd = &DeploymentKindDefinition{}
+
+// +k8s:deepcopy-gen=false
+type Dkd interface {
+	resource.Object
+
+	GetSchema() *apiextensionsv1.JSONSchemaProps
+	GetRegister() map[string]string
+	GetCriticalRegisters() []string
+}
+
+// GetSchema returns nil when the kind does not restrict the attributes of
+// its deployments.
+func (x *DeploymentKindDefinition) GetSchema() *apiextensionsv1.JSONSchemaProps {
+	return x.Spec.Properties.Schema
+}
+
+func (x *DeploymentKindDefinition) GetRegister() map[string]string {
+	s := make(map[string]string)
+	for _, register := range x.Spec.Properties.Register {
+		for kind, name := range register.GetRegister() {
+			s[kind] = name
+		}
+	}
+	return s
+}
+
+func (x *DeploymentKindDefinition) GetCriticalRegisters() []string {
+	return x.Spec.Properties.CriticalRegisters
+}
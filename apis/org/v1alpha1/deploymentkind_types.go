@@ -0,0 +1,85 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DeploymentKindDefinition struct
+type DeploymentKindDefinitionProperties struct {
+	// kubebuilder:validation:MaxLength=255
+	Description *string `json:"description,omitempty"`
+	// Schema is the openapi v3 schema the attributes of the deployments of
+	// the kind are validated against
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	Schema *apiextensionsv1.JSONSchemaProps `json:"schema,omitempty"`
+	// Register holds the default registers of the deployments of the kind,
+	// they take precedence over the registers of the organization
+	Register []*nddov1.Register `json:"register,omitempty"`
+	// CriticalRegisters must be present in the register of the deployments
+	// of the kind, in addition to the registers that are always critical
+	CriticalRegisters []string `json:"critical-registers,omitempty"`
+}
+
+// A DeploymentKindDefinitionSpec defines the desired state of a
+// DeploymentKindDefinition.
+type DeploymentKindDefinitionSpec struct {
+	Properties DeploymentKindDefinitionProperties `json:"properties,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentKindDefinition defines a deployment kind, the name of the
+// definition is the kind of the deployments it applies to.
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="CRITICAL",type="string",JSONPath=".spec.properties.critical-registers"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type DeploymentKindDefinition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentKindDefinitionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentKindDefinitionList contains a list of DeploymentKindDefinitions
+type DeploymentKindDefinitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentKindDefinition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentKindDefinition{}, &DeploymentKindDefinitionList{})
+}
+
+// DeploymentKindDefinition type metadata.
+var (
+	DeploymentKindDefinitionKindKind         = reflect.TypeOf(DeploymentKindDefinition{}).Name()
+	DeploymentKindDefinitionGroupKind        = schema.GroupKind{Group: Group, Kind: DeploymentKindDefinitionKindKind}.String()
+	DeploymentKindDefinitionKindAPIVersion   = DeploymentKindDefinitionKindKind + "." + GroupVersion.String()
+	DeploymentKindDefinitionGroupVersionKind = GroupVersion.WithKind(DeploymentKindDefinitionKindKind)
+)
@@ -72,15 +72,21 @@ type Org interface {
 	GetDescription() string
 	GetRegister() map[string]string
 	GetAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
+	GetASN() int64
+	GetDerivedTemplates() map[string]string
+	GetConfigMapPublication() *ConfigMapPublication
 
 	InitializeResource() error
 	SetStatus(string)
 	SetReason(string)
 	GetStatus() string
+	GetReason() string
 	GetStateRegister() map[string]string
 	SetStateRegister(map[string]string)
 	GetStateAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
 	SetStateAddressAllocationStrategy(*nddov1.AddressAllocationStrategy)
+	GetStateOrganizationID() int64
+	SetStateOrganizationID(int64)
 }
 
 // GetCondition of this Network Node.
@@ -160,6 +166,24 @@ func (x *Organization) GetAddressAllocationStrategy() *nddov1.AddressAllocationS
 	return x.Spec.Properties.AddressAllocationStrategy
 }
 
+// GetASN returns 0 when the organization has no autonomous system number.
+func (x *Organization) GetASN() int64 {
+	if x.Spec.Properties.ASN == nil {
+		return 0
+	}
+	return *x.Spec.Properties.ASN
+}
+
+func (x *Organization) GetDerivedTemplates() map[string]string {
+	return x.Spec.Properties.DerivedTemplates
+}
+
+// GetConfigMapPublication returns nil when the organization does not publish
+// its deployments in ConfigMaps.
+func (x *Organization) GetConfigMapPublication() *ConfigMapPublication {
+	return x.Spec.Properties.ConfigMap
+}
+
 func (x *Organization) InitializeResource() error {
 	if x.Status.Organization != nil {
 		// resource was already initialiazed
@@ -193,6 +217,13 @@ func (x *Organization) GetStatus() string {
 	return "unknown"
 }
 
+func (x *Organization) GetReason() string {
+	if x.Status.Organization != nil && x.Status.Organization.State != nil && x.Status.Organization.State.Reason != nil {
+		return *x.Status.Organization.State.Reason
+	}
+	return ""
+}
+
 func (x *Organization) GetStateRegister() map[string]string {
 	r := make(map[string]string)
 	if x.Status.Organization != nil && x.Status.Organization.State != nil && x.Status.Organization.State.Status != nil {
@@ -206,13 +237,7 @@ func (x *Organization) GetStateRegister() map[string]string {
 }
 
 func (x *Organization) SetStateRegister(r map[string]string) {
-	x.Status.Organization.Register = make([]*nddov1.Register, 0, len(r))
-	for kind, name := range r {
-		x.Status.Organization.Register = append(x.Status.Organization.Register, &nddov1.Register{
-			Kind: utils.StringPtr(kind),
-			Name: utils.StringPtr(name),
-		})
-	}
+	x.Status.Organization.Register = registerList(r)
 }
 
 func (x *Organization) GetStateAddressAllocationStrategy() *nddov1.AddressAllocationStrategy {
@@ -225,3 +250,30 @@ func (x *Organization) GetStateAddressAllocationStrategy() *nddov1.AddressAlloca
 func (x *Organization) SetStateAddressAllocationStrategy(a *nddov1.AddressAllocationStrategy) {
 	x.Status.Organization.AddressAllocationStrategy = a
 }
+
+// GetStateOrganizationID returns 0 when no id is allocated yet.
+func (x *Organization) GetStateOrganizationID() int64 {
+	if x.Status.Organization != nil {
+		return idValue(x.Status.Organization.OrganizationID)
+	}
+	return 0
+}
+
+func (x *Organization) SetStateOrganizationID(id int64) {
+	x.Status.Organization.OrganizationID = idPtr(id)
+}
+
+// idPtr returns nil for the id 0, which is never allocated.
+func idPtr(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func idValue(id *int64) int64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -29,6 +29,9 @@ type NddrOrganization struct {
 	Register                  []*nddov1.Register                `json:"register,omitempty"`
 	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
 	State                     *NddrOrgDeploymentState           `json:"state,omitempty"`
+	// OrganizationID is unique in the cluster and never changes while the
+	// organization exists
+	OrganizationID *int64 `json:"organization-id,omitempty"`
 }
 
 type NddrOrganizationState struct {
@@ -45,6 +48,24 @@ type OrganizationProperties struct {
 	Description               *string                           `json:"description,omitempty"`
 	Register                  []*nddov1.Register                `json:"register,omitempty"`
 	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+	// ASN is the autonomous system number of the organization
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4294967295
+	ASN *int64 `json:"asn,omitempty"`
+	// DerivedTemplates are go templates of the identifiers that are derived
+	// for every deployment of the organization, e.g.
+	// rt-base: "{{.OrgASN}}:{{.DeploymentID}}00"
+	DerivedTemplates map[string]string `json:"derived-templates,omitempty"`
+	// ConfigMap publishes the effective register of every deployment of the
+	// organization in a ConfigMap, for consumers that cannot use the registry
+	ConfigMap *ConfigMapPublication `json:"config-map,omitempty"`
+}
+
+// ConfigMapPublication configures the ConfigMaps of the deployments of an
+// organization.
+type ConfigMapPublication struct {
+	// Namespace of the ConfigMaps, the namespace of the deployment when empty
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // A OrganizationSpec defines the desired state of a Organization.
@@ -66,6 +87,7 @@ type OrganizationStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="SYNC",type="string",JSONPath=".status.conditions[?(@.kind=='Synced')].status"
 // +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.conditions[?(@.kind=='Ready')].status"
+// +kubebuilder:printcolumn:name="ID",type="integer",JSONPath=".status.organization.organization-id"
 // +kubebuilder:printcolumn:name="IPAM",type="string",JSONPath=".status.organization.register[?(@.kind=='ipam')].name"
 // +kubebuilder:printcolumn:name="NI",type="string",JSONPath=".status.organization.register[?(@.kind=='ni')].name"
 // +kubebuilder:printcolumn:name="AS",type="string",JSONPath=".status.organization.register[?(@.kind=='as')].name"
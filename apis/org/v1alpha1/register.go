@@ -0,0 +1,43 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sort"
+
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+)
+
+// registerList returns the register entries sorted by kind, so the same
+// register always results in the same status.
+func registerList(r map[string]string) []*nddov1.Register {
+	kinds := make([]string, 0, len(r))
+	for kind := range r {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	l := make([]*nddov1.Register, 0, len(r))
+	for _, kind := range kinds {
+		l = append(l, &nddov1.Register{
+			Kind: utils.StringPtr(kind),
+			Name: utils.StringPtr(r[kind]),
+		})
+	}
+	return l
+}
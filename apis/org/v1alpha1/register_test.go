@@ -0,0 +1,88 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetStateRegisterIsSorted(t *testing.T) {
+	register := map[string]string{
+		"vlan":     "nokia-vlan",
+		"as":       "nokia-as",
+		"ipam":     "nokia-ipam",
+		"nodename": "nokia-nodename",
+		"esi":      "nokia-esi",
+	}
+	want := []string{"as", "esi", "ipam", "nodename", "vlan"}
+
+	cases := map[string]interface {
+		InitializeResource() error
+		SetStateRegister(map[string]string)
+		stateKinds() []string
+	}{
+		"Organization": &orgKinds{&Organization{}},
+		"Deployment":   &depKinds{&Deployment{}},
+	}
+
+	for name, cr := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := cr.InitializeResource(); err != nil {
+				t.Fatalf("InitializeResource(): %v", err)
+			}
+			// map iteration order is random, every run must give the same list
+			for i := 0; i < 20; i++ {
+				cr.SetStateRegister(register)
+				if got := cr.stateKinds(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("SetStateRegister(): want kinds %v, got %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSetStateRegisterEmpty(t *testing.T) {
+	cr := &Deployment{}
+	if err := cr.InitializeResource(); err != nil {
+		t.Fatalf("InitializeResource(): %v", err)
+	}
+	cr.SetStateRegister(nil)
+	if cr.Status.Deployment.Register == nil || len(cr.Status.Deployment.Register) != 0 {
+		t.Errorf("SetStateRegister(nil): want an empty register, got %v", cr.Status.Deployment.Register)
+	}
+}
+
+type orgKinds struct{ *Organization }
+
+func (x *orgKinds) stateKinds() []string {
+	kinds := make([]string, 0, len(x.Status.Organization.Register))
+	for _, r := range x.Status.Organization.Register {
+		kinds = append(kinds, *r.Kind)
+	}
+	return kinds
+}
+
+type depKinds struct{ *Deployment }
+
+func (x *depKinds) stateKinds() []string {
+	kinds := make([]string, 0, len(x.Status.Deployment.Register))
+	for _, r := range x.Status.Deployment.Register {
+		kinds = append(kinds, *r.Kind)
+	}
+	return kinds
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ RpList = &RegisterPolicyList{}
+
+// +k8s:deepcopy-gen=false
+type RpList interface {
+	client.ObjectList
+
+	GetRegisterPolicies() []Rp
+}
+
+func (x *RegisterPolicyList) GetRegisterPolicies() []Rp {
+	xs := make([]Rp, len(x.Items))
+	for i, r := range x.Items {
+		r := r // Pin range variable so we can take its address.
+		xs[i] = &r
+	}
+	return xs
+}
+
+var _ Rp = &RegisterPolicy{}
+
+// +k8s:deepcopy-gen=false
+type Rp interface {
+	client.Object
+
+	GetOrganizationName() string
+	GetRules() []*RegisterPolicyRule
+}
+
+func (x *RegisterPolicy) GetOrganizationName() string {
+	return x.Spec.Properties.Organization
+}
+
+func (x *RegisterPolicy) GetRules() []*RegisterPolicyRule {
+	return x.Spec.Properties.Rules
+}
+
+// Matches returns true when the deployment has the kind, region and labels
+// of the rule, defaults of the deployment class included.
+func (x *RegisterPolicyRule) Matches(dep Dp) (bool, error) {
+	if x.Match.Kind != nil && *x.Match.Kind != dep.GetKind() {
+		return false, nil
+	}
+	if x.Match.Region != nil && *x.Match.Region != dep.GetRegion() {
+		return false, nil
+	}
+	if x.Match.LabelSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(x.Match.LabelSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(dep.GetDeploymentLabels())), nil
+}
+
+func (x *RegisterPolicyRule) GetRegister() map[string]string {
+	s := make(map[string]string)
+	for _, register := range x.Register {
+		for kind, name := range register.GetRegister() {
+			s[kind] = name
+		}
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RegisterPolicyMatch selects deployments, empty fields match every
+// deployment.
+type RegisterPolicyMatch struct {
+	Kind   *string `json:"kind,omitempty"`
+	Region *string `json:"region,omitempty"`
+	// LabelSelector matches the labels of the deployment
+	LabelSelector *metav1.LabelSelector `json:"label-selector,omitempty"`
+}
+
+// RegisterPolicyRule overrides the organization register of the deployments
+// it matches.
+type RegisterPolicyRule struct {
+	// Name identifies the rule in the status of the deployments it matches
+	// +kubebuilder:validation:MinLength=1
+	Name     string              `json:"name"`
+	Match    RegisterPolicyMatch `json:"match,omitempty"`
+	Register []*nddov1.Register  `json:"register,omitempty"`
+}
+
+// RegisterPolicy struct
+type RegisterPolicyProperties struct {
+	// Organization the policy applies to, the organization lives in the
+	// namespace of the policy
+	// +kubebuilder:validation:MinLength=1
+	Organization string `json:"organization"`
+	// Rules are evaluated in order, the first rule that matches a deployment
+	// applies
+	Rules []*RegisterPolicyRule `json:"rules,omitempty"`
+}
+
+// A RegisterPolicySpec defines the desired state of a RegisterPolicy.
+type RegisterPolicySpec struct {
+	Properties RegisterPolicyProperties `json:"properties,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RegisterPolicy selects the register of deployments by their kind, region
+// and labels. The register of the matching rule takes precedence over the
+// organization register, the register of the deployment over both.
+// +kubebuilder:printcolumn:name="ORG",type="string",JSONPath=".spec.properties.organization"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type RegisterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RegisterPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RegisterPolicyList contains a list of RegisterPolicies
+type RegisterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RegisterPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RegisterPolicy{}, &RegisterPolicyList{})
+}
+
+// RegisterPolicy type metadata.
+var (
+	RegisterPolicyKindKind         = reflect.TypeOf(RegisterPolicy{}).Name()
+	RegisterPolicyGroupKind        = schema.GroupKind{Group: Group, Kind: RegisterPolicyKindKind}.String()
+	RegisterPolicyKindAPIVersion   = RegisterPolicyKindKind + "." + GroupVersion.String()
+	RegisterPolicyGroupVersionKind = GroupVersion.WithKind(RegisterPolicyKindKind)
+)
@@ -23,9 +23,41 @@ package v1alpha1
 
 import (
 	"github.com/yndd/nddo-runtime/apis/common/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapPublication) DeepCopyInto(out *ConfigMapPublication) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapPublication.
+func (in *ConfigMapPublication) DeepCopy() *ConfigMapPublication {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapPublication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Deployment) DeepCopyInto(out *Deployment) {
 	*out = *in
@@ -53,6 +85,248 @@ func (in *Deployment) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentClass) DeepCopyInto(out *DeploymentClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentClass.
+func (in *DeploymentClass) DeepCopy() *DeploymentClass {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentClassList) DeepCopyInto(out *DeploymentClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeploymentClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentClassList.
+func (in *DeploymentClassList) DeepCopy() *DeploymentClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentClassProperties) DeepCopyInto(out *DeploymentClassProperties) {
+	*out = *in
+	if in.Description != nil {
+		in, out := &in.Description, &out.Description
+		*out = new(string)
+		**out = **in
+	}
+	if in.AdminState != nil {
+		in, out := &in.AdminState, &out.AdminState
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Register != nil {
+		in, out := &in.Register, &out.Register
+		*out = make([]*v1.Register, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(v1.Register)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.AddressAllocationStrategy != nil {
+		in, out := &in.AddressAllocationStrategy, &out.AddressAllocationStrategy
+		*out = new(v1.AddressAllocationStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentClassProperties.
+func (in *DeploymentClassProperties) DeepCopy() *DeploymentClassProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentClassProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentClassSpec) DeepCopyInto(out *DeploymentClassSpec) {
+	*out = *in
+	in.Properties.DeepCopyInto(&out.Properties)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentClassSpec.
+func (in *DeploymentClassSpec) DeepCopy() *DeploymentClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentKindDefinition) DeepCopyInto(out *DeploymentKindDefinition) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentKindDefinition.
+func (in *DeploymentKindDefinition) DeepCopy() *DeploymentKindDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentKindDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentKindDefinition) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentKindDefinitionList) DeepCopyInto(out *DeploymentKindDefinitionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeploymentKindDefinition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentKindDefinitionList.
+func (in *DeploymentKindDefinitionList) DeepCopy() *DeploymentKindDefinitionList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentKindDefinitionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentKindDefinitionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentKindDefinitionProperties) DeepCopyInto(out *DeploymentKindDefinitionProperties) {
+	*out = *in
+	if in.Description != nil {
+		in, out := &in.Description, &out.Description
+		*out = new(string)
+		**out = **in
+	}
+	if in.Schema != nil {
+		in, out := &in.Schema, &out.Schema
+		*out = new(apiextensionsv1.JSONSchemaProps)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Register != nil {
+		in, out := &in.Register, &out.Register
+		*out = make([]*v1.Register, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(v1.Register)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.CriticalRegisters != nil {
+		in, out := &in.CriticalRegisters, &out.CriticalRegisters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentKindDefinitionProperties.
+func (in *DeploymentKindDefinitionProperties) DeepCopy() *DeploymentKindDefinitionProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentKindDefinitionProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentKindDefinitionSpec) DeepCopyInto(out *DeploymentKindDefinitionSpec) {
+	*out = *in
+	in.Properties.DeepCopyInto(&out.Properties)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentKindDefinitionSpec.
+func (in *DeploymentKindDefinitionSpec) DeepCopy() *DeploymentKindDefinitionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentKindDefinitionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentList) DeepCopyInto(out *DeploymentList) {
 	*out = *in
@@ -88,6 +362,16 @@ func (in *DeploymentList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentProperties) DeepCopyInto(out *DeploymentProperties) {
 	*out = *in
+	if in.OrganizationRef != nil {
+		in, out := &in.OrganizationRef, &out.OrganizationRef
+		*out = new(OrganizationReference)
+		**out = **in
+	}
+	if in.DeploymentClassName != nil {
+		in, out := &in.DeploymentClassName, &out.DeploymentClassName
+		*out = new(string)
+		**out = **in
+	}
 	if in.AdminState != nil {
 		in, out := &in.AdminState, &out.AdminState
 		*out = new(string)
@@ -124,6 +408,11 @@ func (in *DeploymentProperties) DeepCopyInto(out *DeploymentProperties) {
 		*out = new(v1.AddressAllocationStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentProperties.
@@ -198,6 +487,43 @@ func (in *NddrOrgDeployment) DeepCopyInto(out *NddrOrgDeployment) {
 		*out = new(NddrOrgDeploymentState)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RegisterPolicy != nil {
+		in, out := &in.RegisterPolicy, &out.RegisterPolicy
+		*out = new(RegisterPolicyRuleReference)
+		**out = **in
+	}
+	if in.DeploymentClass != nil {
+		in, out := &in.DeploymentClass, &out.DeploymentClass
+		*out = new(ResolvedDeploymentClass)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CriticalRegisters != nil {
+		in, out := &in.CriticalRegisters, &out.CriticalRegisters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OrganizationID != nil {
+		in, out := &in.OrganizationID, &out.OrganizationID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DeploymentID != nil {
+		in, out := &in.DeploymentID, &out.DeploymentID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Derived != nil {
+		in, out := &in.Derived, &out.Derived
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NddrOrgDeployment.
@@ -259,6 +585,11 @@ func (in *NddrOrganization) DeepCopyInto(out *NddrOrganization) {
 		*out = new(NddrOrgDeploymentState)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OrganizationID != nil {
+		in, out := &in.OrganizationID, &out.OrganizationID
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NddrOrganization.
@@ -379,6 +710,23 @@ func (in *OrganizationProperties) DeepCopyInto(out *OrganizationProperties) {
 		*out = new(v1.AddressAllocationStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ASN != nil {
+		in, out := &in.ASN, &out.ASN
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DerivedTemplates != nil {
+		in, out := &in.DerivedTemplates, &out.DerivedTemplates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapPublication)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationProperties.
@@ -391,6 +739,21 @@ func (in *OrganizationProperties) DeepCopy() *OrganizationProperties {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationReference) DeepCopyInto(out *OrganizationReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationReference.
+func (in *OrganizationReference) DeepCopy() *OrganizationReference {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrganizationSpec) DeepCopyInto(out *OrganizationSpec) {
 	*out = *in
@@ -428,3 +791,207 @@ func (in *OrganizationStatus) DeepCopy() *OrganizationStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicy) DeepCopyInto(out *RegisterPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicy.
+func (in *RegisterPolicy) DeepCopy() *RegisterPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegisterPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicyList) DeepCopyInto(out *RegisterPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RegisterPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicyList.
+func (in *RegisterPolicyList) DeepCopy() *RegisterPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegisterPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicyMatch) DeepCopyInto(out *RegisterPolicyMatch) {
+	*out = *in
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Region != nil {
+		in, out := &in.Region, &out.Region
+		*out = new(string)
+		**out = **in
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicyMatch.
+func (in *RegisterPolicyMatch) DeepCopy() *RegisterPolicyMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicyMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicyProperties) DeepCopyInto(out *RegisterPolicyProperties) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]*RegisterPolicyRule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(RegisterPolicyRule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicyProperties.
+func (in *RegisterPolicyProperties) DeepCopy() *RegisterPolicyProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicyProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicyRule) DeepCopyInto(out *RegisterPolicyRule) {
+	*out = *in
+	in.Match.DeepCopyInto(&out.Match)
+	if in.Register != nil {
+		in, out := &in.Register, &out.Register
+		*out = make([]*v1.Register, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(v1.Register)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicyRule.
+func (in *RegisterPolicyRule) DeepCopy() *RegisterPolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicyRuleReference) DeepCopyInto(out *RegisterPolicyRuleReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicyRuleReference.
+func (in *RegisterPolicyRuleReference) DeepCopy() *RegisterPolicyRuleReference {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicyRuleReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegisterPolicySpec) DeepCopyInto(out *RegisterPolicySpec) {
+	*out = *in
+	in.Properties.DeepCopyInto(&out.Properties)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegisterPolicySpec.
+func (in *RegisterPolicySpec) DeepCopy() *RegisterPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RegisterPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedDeploymentClass) DeepCopyInto(out *ResolvedDeploymentClass) {
+	*out = *in
+	if in.AdminState != nil {
+		in, out := &in.AdminState, &out.AdminState
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedDeploymentClass.
+func (in *ResolvedDeploymentClass) DeepCopy() *ResolvedDeploymentClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedDeploymentClass)
+	in.DeepCopyInto(out)
+	return out
+}
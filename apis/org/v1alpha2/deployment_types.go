@@ -0,0 +1,122 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"reflect"
+
+	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DeploymentProperties define the properties of a deployment.
+type DeploymentProperties struct {
+	// OrganizationRef references the organization of the deployment. The
+	// organization is derived from the odns name of the deployment when
+	// omitted.
+	// +optional
+	OrganizationRef *OrganizationRef `json:"organizationRef,omitempty"`
+	// DeploymentClassName references the deployment class in the namespace
+	// of the deployment, the class provides the defaults of unset fields.
+	// +optional
+	DeploymentClassName string `json:"deploymentClassName,omitempty"`
+	// AdminState defaults to the admin state of the class, a deployment
+	// without either is enabled.
+	// +optional
+	AdminState AdminState `json:"adminState,omitempty"`
+	// +kubebuilder:validation:MaxLength=255
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Kind defaults to the kind of the class. Kinds other than dc and wan
+	// need a DeploymentKindDefinition.
+	// +optional
+	Kind DeploymentKind `json:"kind,omitempty"`
+	// Registers override the registers inherited from the organization.
+	// +listType=map
+	// +listMapKey=kind
+	// +optional
+	Registers []Register `json:"registers,omitempty"`
+	// +optional
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"addressAllocationStrategy,omitempty"`
+	// Attributes are the kind specific attributes of the deployment, they
+	// are validated against the schema of the kind.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Attributes *apiextensionsv1.JSON `json:"attributes,omitempty"`
+}
+
+// A DeploymentSpec defines the desired state of a Deployment.
+type DeploymentSpec struct {
+	nddv1.ResourceSpec `json:",inline"`
+	Properties         DeploymentProperties `json:"properties,omitempty"`
+}
+
+// A DeploymentStatus represents the observed state of a Deployment.
+type DeploymentStatus struct {
+	nddv1.ResourceStatus `json:",inline"`
+	ObservedState        `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// Deployment is the Schema for the Deployment API
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SYNC",type="string",JSONPath=".status.conditions[?(@.kind=='Synced')].status"
+// +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.conditions[?(@.kind=='Ready')].status"
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="ORG",type="string",JSONPath=".spec.properties.organizationRef.name"
+// +kubebuilder:printcolumn:name="ID",type="integer",JSONPath=".status.deploymentID"
+// +kubebuilder:printcolumn:name="IPAM",type="string",JSONPath=".status.registers[?(@.kind=='ipam')].name"
+// +kubebuilder:printcolumn:name="NI",type="string",JSONPath=".status.registers[?(@.kind=='ni')].name"
+// +kubebuilder:printcolumn:name="AS",type="string",JSONPath=".status.registers[?(@.kind=='as')].name"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type Deployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeploymentSpec   `json:"spec,omitempty"`
+	Status DeploymentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentList contains a list of Deployments
+type DeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Deployment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Deployment{}, &DeploymentList{})
+}
+
+// Deployment type metadata.
+var (
+	DeploymentKindKind         = reflect.TypeOf(Deployment{}).Name()
+	DeploymentGroupKind        = schema.GroupKind{Group: Group, Kind: DeploymentKindKind}.String()
+	DeploymentKindAPIVersion   = DeploymentKindKind + "." + GroupVersion.String()
+	DeploymentGroupVersionKind = GroupVersion.WithKind(DeploymentKindKind)
+)
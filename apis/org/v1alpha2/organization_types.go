@@ -0,0 +1,107 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"reflect"
+
+	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// OrganizationProperties define the properties of an organization.
+type OrganizationProperties struct {
+	// +kubebuilder:validation:MaxLength=255
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Registers the deployments of the organization inherit.
+	// +listType=map
+	// +listMapKey=kind
+	// +optional
+	Registers []Register `json:"registers,omitempty"`
+	// +optional
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"addressAllocationStrategy,omitempty"`
+	// ASN is the autonomous system number of the organization.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	ASN int64 `json:"asn,omitempty"`
+	// DerivedTemplates are go templates of the identifiers that are derived
+	// for every deployment of the organization.
+	// +optional
+	DerivedTemplates map[string]string `json:"derivedTemplates,omitempty"`
+	// ConfigMap publishes the effective register of every deployment of the
+	// organization in a ConfigMap.
+	// +optional
+	ConfigMap *ConfigMapPublication `json:"configMap,omitempty"`
+}
+
+// A OrganizationSpec defines the desired state of a Organization.
+type OrganizationSpec struct {
+	nddv1.ResourceSpec `json:",inline"`
+	Properties         OrganizationProperties `json:"properties,omitempty"`
+}
+
+// A OrganizationStatus represents the observed state of a Organization.
+type OrganizationStatus struct {
+	nddv1.ResourceStatus `json:",inline"`
+	ObservedState        `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// Organization is the Schema for the Organization API
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SYNC",type="string",JSONPath=".status.conditions[?(@.kind=='Synced')].status"
+// +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.conditions[?(@.kind=='Ready')].status"
+// +kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="ID",type="integer",JSONPath=".status.organizationID"
+// +kubebuilder:printcolumn:name="IPAM",type="string",JSONPath=".status.registers[?(@.kind=='ipam')].name"
+// +kubebuilder:printcolumn:name="NI",type="string",JSONPath=".status.registers[?(@.kind=='ni')].name"
+// +kubebuilder:printcolumn:name="AS",type="string",JSONPath=".status.registers[?(@.kind=='as')].name"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type Organization struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrganizationSpec   `json:"spec,omitempty"`
+	Status OrganizationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OrganizationList contains a list of Organizations
+type OrganizationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Organization `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Organization{}, &OrganizationList{})
+}
+
+// Organization type metadata.
+var (
+	OrganizationKindKind         = reflect.TypeOf(Organization{}).Name()
+	OrganizationGroupKind        = schema.GroupKind{Group: Group, Kind: OrganizationKindKind}.String()
+	OrganizationKindAPIVersion   = OrganizationKindKind + "." + GroupVersion.String()
+	OrganizationGroupVersionKind = GroupVersion.WithKind(OrganizationKindKind)
+)
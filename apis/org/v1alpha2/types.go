@@ -0,0 +1,146 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+)
+
+// State is the observed state of an organization or deployment.
+// +kubebuilder:validation:Enum=up;down;unknown
+type State string
+
+const (
+	StateUp      State = "up"
+	StateDown    State = "down"
+	StateUnknown State = "unknown"
+)
+
+// AdminState is the administrative state of a deployment.
+// +kubebuilder:validation:Enum=enable;disable
+type AdminState string
+
+const (
+	AdminStateEnable  AdminState = "enable"
+	AdminStateDisable AdminState = "disable"
+)
+
+// DeploymentKind is the kind of network a deployment describes, kinds other
+// than dc and wan are defined by a DeploymentKindDefinition.
+type DeploymentKind string
+
+const (
+	DeploymentKindDC  DeploymentKind = "dc"
+	DeploymentKindWAN DeploymentKind = "wan"
+)
+
+// A Register names the registry that serves a register kind, e.g. the ipam
+// registry of an organization.
+type Register struct {
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// An OrganizationRef references the organization of a deployment.
+type OrganizationRef struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the organization, the namespace of the deployment when
+	// empty.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ObservedState is the state the controllers derived for an organization or
+// deployment.
+type ObservedState struct {
+	// +optional
+	State State `json:"state,omitempty"`
+	// Reason the state is down.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Registers is the effective register.
+	// +listType=map
+	// +listMapKey=kind
+	// +optional
+	Registers []Register `json:"registers,omitempty"`
+	// +optional
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"addressAllocationStrategy,omitempty"`
+	// RegisterPolicy is the register policy rule that matched a deployment.
+	// +optional
+	RegisterPolicy *RegisterPolicyRuleRef `json:"registerPolicy,omitempty"`
+	// DeploymentClass is the class the defaults of a deployment were
+	// resolved from.
+	// +optional
+	DeploymentClass *ResolvedDeploymentClass `json:"deploymentClass,omitempty"`
+	// CriticalRegisters are the critical registers of the kind of a
+	// deployment.
+	// +optional
+	CriticalRegisters []string `json:"criticalRegisters,omitempty"`
+	// OrganizationID is the cluster unique id of an organization, or of the
+	// organization of a deployment.
+	// +optional
+	OrganizationID *int64 `json:"organizationID,omitempty"`
+	// DeploymentID is the organization unique id of a deployment.
+	// +optional
+	DeploymentID *int64 `json:"deploymentID,omitempty"`
+	// Derived are the identifiers rendered from the derived templates of the
+	// organization of a deployment.
+	// +optional
+	Derived map[string]string `json:"derived,omitempty"`
+	// ConfigMap is the ConfigMap a deployment is published in.
+	// +optional
+	ConfigMap *ConfigMapRef `json:"configMap,omitempty"`
+}
+
+// A ConfigMapPublication configures the ConfigMaps of the deployments of an
+// organization.
+type ConfigMapPublication struct {
+	// Namespace of the ConfigMaps, the namespace of the deployment when
+	// empty.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// A ConfigMapRef references the ConfigMap of a deployment.
+type ConfigMapRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// A ResolvedDeploymentClass is the deployment class a deployment was
+// reconciled with.
+type ResolvedDeploymentClass struct {
+	Name string `json:"name"`
+	// Generation of the class.
+	// +optional
+	Generation int64 `json:"generation,omitempty"`
+	// +optional
+	AdminState AdminState `json:"adminState,omitempty"`
+	// +optional
+	Kind DeploymentKind `json:"kind,omitempty"`
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// A RegisterPolicyRuleRef references a rule of a register policy.
+type RegisterPolicyRuleRef struct {
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package intent
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	orgv1alpha2 "github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
+)
+
+const (
+	// errors
+	errMigrateList          = "cannot list %s"
+	errMigrateUpdate        = "cannot rewrite %s %s/%s"
+	errMigrateGetCRD        = "cannot get crd %s"
+	errMigrateStoredVersion = "cannot update stored versions of crd %s"
+)
+
+var migrateDryRun bool
+
+// migrateCmd rewrites all organizations and deployments in the storage
+// version and drops the old versions from the stored versions of the CRDs.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate organizations and deployments to the storage version",
+	Long: "migrate organizations and deployments to the storage version. " +
+		"The conversion webhook has to be served while the objects are rewritten.",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := runtime.NewScheme()
+		utilruntime.Must(orgv1alpha2.AddToScheme(s))
+		utilruntime.Must(apiextensionsv1.AddToScheme(s))
+
+		c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: s})
+		if err != nil {
+			return errors.Wrap(err, "cannot create client")
+		}
+		if migrateDryRun {
+			c = client.NewDryRunClient(c)
+		}
+		ctx := cmd.Context()
+
+		resources := []struct {
+			plural string
+			list   client.ObjectList
+		}{
+			{plural: "organizations", list: &orgv1alpha2.OrganizationList{}},
+			{plural: "deployments", list: &orgv1alpha2.DeploymentList{}},
+		}
+		for _, r := range resources {
+			n, err := rewrite(ctx, c, r.plural, r.list)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("rewrote %d %s\n", n, r.plural)
+			if err := setStoredVersion(ctx, c, r.plural+"."+orgv1alpha2.Group); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "", false, "Validate the migration on the api server without persisting it.")
+}
+
+// rewrite updates every object of the list unchanged, the api server stores
+// the update in the storage version.
+func rewrite(ctx context.Context, c client.Client, plural string, list client.ObjectList) (int, error) {
+	if err := c.List(ctx, list); err != nil {
+		return 0, errors.Wrapf(err, errMigrateList, plural)
+	}
+	objs, err := apimeta.ExtractList(list)
+	if err != nil {
+		return 0, errors.Wrapf(err, errMigrateList, plural)
+	}
+	n := 0
+	for _, o := range objs {
+		obj, ok := o.(client.Object)
+		if !ok {
+			continue
+		}
+		if err := c.Update(ctx, obj); err != nil {
+			// deleted objects do not have to be migrated, changed objects
+			// were stored in the storage version already
+			if kerrors.IsNotFound(err) || kerrors.IsConflict(err) {
+				continue
+			}
+			return n, errors.Wrapf(err, errMigrateUpdate, plural, obj.GetNamespace(), obj.GetName())
+		}
+		n++
+	}
+	return n, nil
+}
+
+// setStoredVersion drops all but the storage version from the stored
+// versions of the crd, the old versions can be removed from the crd after.
+func setStoredVersion(ctx context.Context, c client.Client, name string) error {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+		return errors.Wrapf(err, errMigrateGetCRD, name)
+	}
+	patch := client.MergeFrom(crd.DeepCopy())
+	crd.Status.StoredVersions = []string{orgv1alpha2.GroupVersion.Version}
+	if err := c.Status().Patch(ctx, crd, patch); err != nil {
+		return errors.Wrapf(err, errMigrateStoredVersion, name)
+	}
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	//ndrv1 "github.com/yndd/ndd-core/apis/dvr/v1"
 	//nipoolv1alpha1 "github.com/yndd/nddr-ni-pool/apis/nipool/v1alpha1"
 	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	orgv1alpha2 "github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
 	//apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	//+kubebuilder:scaffold:imports
 )
@@ -61,5 +62,6 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme))
 	utilruntime.Must(orgv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(orgv1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -17,23 +17,38 @@ limitations under the License.
 package intent
 
 import (
+	"context"
 	"os"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/yndd/ndd-runtime/pkg/logging"
 	"github.com/yndd/ndd-runtime/pkg/ratelimiter"
 
+	orgv1alpha2 "github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
 	"github.com/yndd/nddr-org-registry/internal/controllers"
+	"github.com/yndd/nddr-org-registry/internal/dryrun"
+	"github.com/yndd/nddr-org-registry/internal/grpcserver"
 	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/queryserver"
+	"github.com/yndd/nddr-org-registry/internal/readiness"
+	"github.com/yndd/nddr-org-registry/internal/sharding"
 	"github.com/yndd/nddr-org-registry/internal/shared"
+	"github.com/yndd/nddr-org-registry/internal/tracing"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -46,7 +61,25 @@ var (
 	namespace            string
 	podname              string
 	grpcServerAddress    string
-	grpcQueryAddress     string
+	grpcUsername         string
+	grpcPassword         string
+	grpcTLSCert          string
+	grpcTLSKey           string
+	queryAddress         string
+	otlpEndpoint         string
+	otlpInsecure         bool
+	traceSampleRatio     float64
+	readyzRegisters      bool
+	watchNamespaces      []string
+	configMapNamespaces  []string
+	labelSelector        string
+	crossNamespaceOrgs   bool
+	sharded              bool
+	shardLeaseDuration   time.Duration
+	shardResyncPeriod    time.Duration
+	dryRun               bool
+	conversionWebhook    bool
+	webhookCertDir       string
 )
 
 // startCmd represents the start command for the network device driver
@@ -62,16 +95,60 @@ var startCmd = &cobra.Command{
 			// Only use a logr.Logger when debug is on
 			ctrl.SetLogger(zlog)
 		}
-		zlog.Info("create manager")
-		mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		shutdownTracing, err := tracing.Setup(cmd.Context(), tracing.Options{
+			Endpoint:    otlpEndpoint,
+			Insecure:    otlpInsecure,
+			SampleRatio: traceSampleRatio,
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot set up tracing")
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				zlog.Error(err, "cannot shut down tracing")
+			}
+		}()
+
+		selector, err := labels.Parse(labelSelector)
+		if err != nil {
+			return errors.Wrap(err, "cannot parse label selector")
+		}
+		scopeSelector := selector
+		if sharded {
+			if enableLeaderElection {
+				return errors.New("sharding and leader election are mutually exclusive")
+			}
+			// the cache of a replica only holds its own shard
+			selector = sharding.Selector(podname, selector)
+		}
+
+		var report *dryrun.Report
+		if dryRun {
+			if sharded {
+				return errors.New("sharding is not supported in dry-run mode")
+			}
+			report = dryrun.NewReport(logging.NewLogrLogger(zlog.WithName("dryrun")))
+		}
+
+		zlog.Info("create manager", "namespaces", watchNamespaces, "selector", selector.String(), "dryRun", dryRun)
+		mgrOptions := ctrl.Options{
 			Scheme:                 scheme,
+			NewCache:               shared.NewOrganizationCache(watchNamespaces, selector),
+			ClientDisableCacheFor:  uncachedObjects(watchNamespaces),
 			MetricsBindAddress:     metricsAddr,
 			Port:                   9443,
 			HealthProbeBindAddress: probeAddr,
 			//LeaderElection:         false,
 			LeaderElection:   enableLeaderElection,
 			LeaderElectionID: "c66ce353.ndd.yndd.io",
-		})
+			CertDir:          webhookCertDir,
+		}
+		if dryRun {
+			// writes are recorded in the report and events are dropped
+			mgrOptions.NewClient = dryrun.NewClientFunc(report)
+			mgrOptions.EventBroadcaster = record.NewBroadcaster()
+		}
+		mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOptions)
 		if err != nil {
 			return errors.Wrap(err, "Cannot create manager")
 		}
@@ -79,6 +156,7 @@ var startCmd = &cobra.Command{
 		handler, err := handler.New(
 			handler.WithLogger(logging.NewLogrLogger(zlog.WithName("handler"))),
 			handler.WithClient(mgr.GetClient()),
+			handler.WithPollInterval(pollInterval),
 		)
 		if err != nil {
 			return errors.Wrap(err, "cannot initialize the handler")
@@ -89,6 +167,11 @@ var startCmd = &cobra.Command{
 			Poll:      pollInterval,
 			Namespace: namespace,
 			Handler:   handler,
+			Readiness: readiness.NewReconciles(mgr.GetClient()),
+
+			AllowCrossNamespaceOrganizations: crossNamespaceOrgs,
+			WatchNamespaces:                  watchNamespaces,
+			PublicationNamespaces:            configMapNamespaces,
 		}
 
 		// initialize controllers
@@ -96,14 +179,104 @@ var startCmd = &cobra.Command{
 			return errors.Wrap(err, "Cannot add nddo controllers to manager")
 		}
 
+		// the apis, the registry and its metrics serve all shards, the cache of
+		// a sharded manager only holds the shard of the replica
+		var api cluster.Cluster = mgr
+		if sharded {
+			api, err = shared.NewCluster(mgr, shared.NewOrganizationCache(watchNamespaces, scopeSelector))
+			if err != nil {
+				return errors.Wrap(err, "cannot create the cache of all shards")
+			}
+		}
+
+		reg := registry.New(
+			registry.WithLogger(logging.NewLogrLogger(zlog.WithName("registry"))),
+			registry.WithClient(api.GetClient()),
+			registry.WithReader(mgr.GetAPIReader()),
+			registry.WithInformers(api.GetCache()),
+		)
+
+		if sharded {
+			s := sharding.New(podname, namespace,
+				sharding.WithLogger(logging.NewLogrLogger(zlog.WithName("sharding"))),
+				sharding.WithClient(mgr.GetClient()),
+				sharding.WithReader(mgr.GetAPIReader()),
+				sharding.WithLeaseDuration(shardLeaseDuration),
+				sharding.WithResyncPeriod(shardResyncPeriod),
+				sharding.WithScope(watchNamespaces, scopeSelector),
+			)
+			if err := mgr.Add(s); err != nil {
+				return errors.Wrap(err, "cannot add sharding to manager")
+			}
+		}
+
+		if err := metrics.Registry.Register(registry.NewCollector(api.GetClient())); err != nil {
+			return errors.Wrap(err, "cannot register registry metrics")
+		}
+
+		if grpcServerAddress != "" {
+			srv := grpcserver.New(grpcServerAddress,
+				grpcserver.WithLogger(logging.NewLogrLogger(zlog.WithName("grpcserver"))),
+				grpcserver.WithClient(api.GetClient()),
+				grpcserver.WithRegistry(reg),
+				grpcserver.WithCredentials(grpcUsername, grpcPassword),
+				grpcserver.WithTLS(grpcTLSCert, grpcTLSKey),
+			)
+			if err := mgr.Add(srv); err != nil {
+				return errors.Wrap(err, "cannot add grpc server to manager")
+			}
+		}
+
+		if queryAddress != "" {
+			srv := queryserver.New(queryAddress,
+				queryserver.WithLogger(logging.NewLogrLogger(zlog.WithName("queryserver"))),
+				queryserver.WithClient(api.GetClient()),
+				queryserver.WithRegistry(reg),
+			)
+			if err := mgr.Add(srv); err != nil {
+				return errors.Wrap(err, "cannot add query server to manager")
+			}
+		}
+
+		if conversionWebhook {
+			// the webhook serves /convert for the hub and all its spokes
+			if err := ctrl.NewWebhookManagedBy(mgr).For(&orgv1alpha2.Organization{}).Complete(); err != nil {
+				return errors.Wrap(err, "cannot set up organization conversion webhook")
+			}
+			if err := ctrl.NewWebhookManagedBy(mgr).For(&orgv1alpha2.Deployment{}).Complete(); err != nil {
+				return errors.Wrap(err, "cannot set up deployment conversion webhook")
+			}
+		}
+
+		if dryRun {
+			if err := mgr.AddMetricsExtraHandler("/dry-run", report); err != nil {
+				return errors.Wrap(err, "cannot add dry-run report endpoint")
+			}
+		}
+
 		// +kubebuilder:scaffold:builder
 
 		if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 			return errors.Wrap(err, "unable to set up health check")
 		}
-		if err := mgr.AddReadyzCheck("check", healthz.Ping); err != nil {
+		if err := mgr.AddReadyzCheck("informers", readiness.CacheSynced(mgr.GetCache())); err != nil {
 			return errors.Wrap(err, "unable to set up ready check")
 		}
+		if sharded {
+			if err := mgr.AddReadyzCheck("api-informers", readiness.CacheSynced(api.GetCache())); err != nil {
+				return errors.Wrap(err, "unable to set up ready check")
+			}
+		}
+		if err := mgr.AddReadyzCheck("reconcile", nddcopts.Readiness.Check); err != nil {
+			return errors.Wrap(err, "unable to set up ready check")
+		}
+		if readyzRegisters {
+			for _, register := range registry.CriticalRegisters() {
+				if err := mgr.AddReadyzCheck("register-"+register, readiness.RegisterBackend(reg, register)); err != nil {
+					return errors.Wrap(err, "unable to set up ready check")
+				}
+			}
+		}
 
 		zlog.Info("starting manager")
 		if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -124,7 +297,27 @@ func init() {
 	startCmd.Flags().StringVarP(&namespace, "namespace", "n", os.Getenv("POD_NAMESPACE"), "Namespace used to unpack and run packages.")
 	startCmd.Flags().StringVarP(&podname, "podname", "", os.Getenv("POD_NAME"), "Name from the pod")
 	startCmd.Flags().StringVarP(&grpcServerAddress, "grpc-server-address", "s", "", "The address of the grpc server binds to.")
-	startCmd.Flags().StringVarP(&grpcQueryAddress, "grpc-query-address", "", "", "Validation query address.")
+	startCmd.Flags().StringVarP(&grpcServerAddress, "grpc-query-address", "", "", "The address of the grpc server binds to.")
+	startCmd.Flags().MarkDeprecated("grpc-query-address", "use --grpc-server-address instead") // nolint:errcheck
+	startCmd.Flags().StringVarP(&grpcUsername, "grpc-username", "", os.Getenv("GRPC_USERNAME"), "Username clients of the grpc server have to present.")
+	startCmd.Flags().StringVarP(&grpcPassword, "grpc-password", "", os.Getenv("GRPC_PASSWORD"), "Password clients of the grpc server have to present.")
+	startCmd.Flags().StringVarP(&grpcTLSCert, "grpc-tls-cert", "", "", "Certificate file of the grpc server, plaintext when empty.")
+	startCmd.Flags().StringVarP(&grpcTLSKey, "grpc-tls-key", "", "", "Key file of the grpc server.")
+	startCmd.Flags().StringVarP(&queryAddress, "query-bind-address", "", "", "The address the http/json query api binds to, disabled when empty.")
+	startCmd.Flags().BoolVarP(&readyzRegisters, "readyz-registers", "", false, "Only report ready when the backends of the critical registers are reachable.")
+	startCmd.Flags().StringSliceVarP(&watchNamespaces, "watch-namespaces", "", nil, "Namespaces the manager watches, all namespaces when empty.")
+	startCmd.Flags().StringSliceVarP(&configMapNamespaces, "configmap-namespaces", "", nil, "Namespaces organizations publish ConfigMaps to besides the watched namespaces, only used with --watch-namespaces.")
+	startCmd.Flags().StringVarP(&labelSelector, "label-selector", "", "", "Label selector organizations and deployments must match to be managed.")
+	startCmd.Flags().BoolVarP(&crossNamespaceOrgs, "allow-cross-namespace-organizations", "", false, "Allow deployments to use an organization of another namespace.")
+	startCmd.Flags().BoolVarP(&sharded, "sharding", "", false, "Split organizations and their deployments over all replicas, the replicas coordinate through leases.")
+	startCmd.Flags().DurationVarP(&shardLeaseDuration, "shard-lease-duration", "", 15*time.Second, "How long a replica stays a shard member after its last lease renewal.")
+	startCmd.Flags().DurationVarP(&shardResyncPeriod, "shard-resync-period", "", 30*time.Second, "How often the shard assignment of all organizations and deployments is verified.")
+	startCmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "Reconcile without writing to the cluster, the diffs that would have been applied are logged and served on /dry-run of the metrics endpoint.")
+	startCmd.Flags().BoolVarP(&conversionWebhook, "enable-conversion-webhook", "", false, "Serve the conversion webhook between the v1alpha1 and v1alpha2 organizations and deployments.")
+	startCmd.Flags().StringVarP(&webhookCertDir, "webhook-cert-dir", "", "", "Directory with tls.crt and tls.key of the webhook server, the controller-runtime default when empty.")
+	startCmd.Flags().StringVarP(&otlpEndpoint, "otlp-endpoint", "", "", "The OTLP grpc endpoint traces are exported to, tracing is disabled when empty.")
+	startCmd.Flags().BoolVarP(&otlpInsecure, "otlp-insecure", "", false, "Export traces without TLS.")
+	startCmd.Flags().Float64VarP(&traceSampleRatio, "trace-sample-ratio", "", 1, "Fraction of new traces that are sampled.")
 }
 
 func nddCtlrOptions(c int) controller.Options {
@@ -133,3 +326,13 @@ func nddCtlrOptions(c int) controller.Options {
 		RateLimiter:             ratelimiter.NewDefaultProviderRateLimiter(ratelimiter.DefaultProviderRPS),
 	}
 }
+
+// uncachedObjects returns the objects that are read from the api server. The
+// registry discovers the register backends from pods in the ndd namespace,
+// which is not part of a namespace scoped cache.
+func uncachedObjects(namespaces []string) []client.Object {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	return []client.Object{&corev1.Pod{}}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attributes validates the kind specific attributes of a deployment
+// against the schema of its DeploymentKindDefinition.
+//
+// Only the subset of openapi v3 that describes attributes is supported: type,
+// properties, required, additionalProperties, items, enum, nullable and the
+// length, item count, pattern and numeric bounds.
+package attributes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// errors
+	errDecodeAttributes = "cannot decode attributes"
+)
+
+// Validate returns an error describing every attribute that does not match
+// the schema. A nil schema accepts any attributes, missing attributes are
+// validated as an empty object so required attributes are reported.
+func Validate(schema *apiextensionsv1.JSONSchemaProps, attrs *apiextensionsv1.JSON) error {
+	if schema == nil {
+		return nil
+	}
+	var v interface{} = map[string]interface{}{}
+	if attrs != nil && len(attrs.Raw) > 0 {
+		if err := json.Unmarshal(attrs.Raw, &v); err != nil {
+			return errors.Wrap(err, errDecodeAttributes)
+		}
+	}
+	return validate(field.NewPath("attributes"), schema, v).ToAggregate()
+}
+
+func validate(path *field.Path, s *apiextensionsv1.JSONSchemaProps, v interface{}) field.ErrorList {
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return field.ErrorList{field.Invalid(path, v, fmt.Sprintf("must be of type %s", s.Type))}
+	}
+
+	var errs field.ErrorList
+	if len(s.Enum) > 0 {
+		errs = append(errs, validateEnum(path, s.Enum, v)...)
+	}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if !typeIs(s, "object") {
+			return append(errs, typeInvalid(path, s, v))
+		}
+		errs = append(errs, validateObject(path, s, x)...)
+	case []interface{}:
+		if !typeIs(s, "array") {
+			return append(errs, typeInvalid(path, s, v))
+		}
+		errs = append(errs, validateArray(path, s, x)...)
+	case string:
+		if !typeIs(s, "string") {
+			return append(errs, typeInvalid(path, s, v))
+		}
+		errs = append(errs, validateString(path, s, x)...)
+	case float64:
+		if !typeIs(s, "number", "integer") || (s.Type == "integer" && x != math.Trunc(x)) {
+			return append(errs, typeInvalid(path, s, v))
+		}
+		errs = append(errs, validateNumber(path, s, x)...)
+	case bool:
+		if !typeIs(s, "boolean") {
+			return append(errs, typeInvalid(path, s, v))
+		}
+	}
+	return errs
+}
+
+// typeIs returns true when the schema has no type or one of the supplied
+// types.
+func typeIs(s *apiextensionsv1.JSONSchemaProps, types ...string) bool {
+	if s.Type == "" {
+		return true
+	}
+	for _, t := range types {
+		if s.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func typeInvalid(path *field.Path, s *apiextensionsv1.JSONSchemaProps, v interface{}) *field.Error {
+	return field.Invalid(path, v, fmt.Sprintf("must be of type %s", s.Type))
+}
+
+func validateEnum(path *field.Path, enum []apiextensionsv1.JSON, v interface{}) field.ErrorList {
+	allowed := make([]string, 0, len(enum))
+	for _, e := range enum {
+		var ev interface{}
+		if err := json.Unmarshal(e.Raw, &ev); err != nil {
+			continue
+		}
+		if reflect.DeepEqual(ev, v) {
+			return nil
+		}
+		allowed = append(allowed, string(e.Raw))
+	}
+	return field.ErrorList{field.NotSupported(path, v, allowed)}
+}
+
+func validateObject(path *field.Path, s *apiextensionsv1.JSONSchemaProps, m map[string]interface{}) field.ErrorList {
+	var errs field.ErrorList
+	for _, name := range s.Required {
+		if _, ok := m[name]; !ok {
+			errs = append(errs, field.Required(path.Child(name), ""))
+		}
+	}
+
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if p, ok := s.Properties[name]; ok {
+			p := p
+			errs = append(errs, validate(path.Child(name), &p, m[name])...)
+			continue
+		}
+		// unknown attributes are accepted, unless the schema restricts them
+		if a := s.AdditionalProperties; a != nil {
+			switch {
+			case a.Schema != nil:
+				errs = append(errs, validate(path.Child(name), a.Schema, m[name])...)
+			case !a.Allows:
+				errs = append(errs, field.Forbidden(path.Child(name), "unknown attribute"))
+			}
+		}
+	}
+	return errs
+}
+
+func validateArray(path *field.Path, s *apiextensionsv1.JSONSchemaProps, l []interface{}) field.ErrorList {
+	var errs field.ErrorList
+	if s.MinItems != nil && int64(len(l)) < *s.MinItems {
+		errs = append(errs, field.Invalid(path, len(l), fmt.Sprintf("must have at least %d items", *s.MinItems)))
+	}
+	if s.MaxItems != nil && int64(len(l)) > *s.MaxItems {
+		errs = append(errs, field.TooMany(path, len(l), int(*s.MaxItems)))
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		for i, item := range l {
+			errs = append(errs, validate(path.Index(i), s.Items.Schema, item)...)
+		}
+	}
+	return errs
+}
+
+func validateString(path *field.Path, s *apiextensionsv1.JSONSchemaProps, str string) field.ErrorList {
+	var errs field.ErrorList
+	n := int64(utf8.RuneCountInString(str))
+	if s.MinLength != nil && n < *s.MinLength {
+		errs = append(errs, field.Invalid(path, str, fmt.Sprintf("must be at least %d characters long", *s.MinLength)))
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		errs = append(errs, field.TooLong(path, str, int(*s.MaxLength)))
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			errs = append(errs, field.InternalError(path, errors.Wrapf(err, "invalid pattern %q", s.Pattern)))
+		} else if !re.MatchString(str) {
+			errs = append(errs, field.Invalid(path, str, fmt.Sprintf("must match %q", s.Pattern)))
+		}
+	}
+	return errs
+}
+
+func validateNumber(path *field.Path, s *apiextensionsv1.JSONSchemaProps, f float64) field.ErrorList {
+	var errs field.ErrorList
+	if m := s.Minimum; m != nil {
+		if f < *m || (s.ExclusiveMinimum && f == *m) {
+			errs = append(errs, field.Invalid(path, f, "must be greater than "+bound(*m, s.ExclusiveMinimum)))
+		}
+	}
+	if m := s.Maximum; m != nil {
+		if f > *m || (s.ExclusiveMaximum && f == *m) {
+			errs = append(errs, field.Invalid(path, f, "must be less than "+bound(*m, s.ExclusiveMaximum)))
+		}
+	}
+	return errs
+}
+
+func bound(m float64, exclusive bool) string {
+	if exclusive {
+		return fmt.Sprintf("%v", m)
+	}
+	return fmt.Sprintf("or equal to %v", m)
+}
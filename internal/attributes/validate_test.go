@@ -0,0 +1,143 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attributes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func schema(t *testing.T, s string) *apiextensionsv1.JSONSchemaProps {
+	t.Helper()
+	p := &apiextensionsv1.JSONSchemaProps{}
+	if err := json.Unmarshal([]byte(s), p); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	return p
+}
+
+func attrs(s string) *apiextensionsv1.JSON {
+	if s == "" {
+		return nil
+	}
+	return &apiextensionsv1.JSON{Raw: []byte(s)}
+}
+
+func TestValidate(t *testing.T) {
+	const dc = `{
+		"type": "object",
+		"required": ["fabric-size"],
+		"properties": {
+			"fabric-size": {"type": "integer", "minimum": 1, "maximum": 64},
+			"tier": {"type": "string", "enum": ["gold", "silver"]},
+			"pods": {"type": "array", "maxItems": 2, "items": {"type": "string", "pattern": "^pod[0-9]+$"}}
+		}
+	}`
+	const strict = `{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"peering-asn": {"type": "integer", "minimum": 0, "exclusiveMinimum": true}
+		}
+	}`
+
+	cases := map[string]struct {
+		schema string
+		attrs  string
+		// want are the substrings of the error, no error when empty
+		want []string
+	}{
+		"NoSchema": {
+			attrs: `{"anything": true}`,
+		},
+		"Valid": {
+			schema: dc,
+			attrs:  `{"fabric-size": 8, "tier": "gold", "pods": ["pod1"], "other": "x"}`,
+		},
+		"MissingRequired": {
+			schema: dc,
+			want:   []string{"attributes.fabric-size: Required value"},
+		},
+		"NotAnInteger": {
+			schema: dc,
+			attrs:  `{"fabric-size": 1.5}`,
+			want:   []string{"attributes.fabric-size", "must be of type integer"},
+		},
+		"OutOfRange": {
+			schema: dc,
+			attrs:  `{"fabric-size": 65}`,
+			want:   []string{"must be less than or equal to 64"},
+		},
+		"NotInEnum": {
+			schema: dc,
+			attrs:  `{"fabric-size": 8, "tier": "bronze"}`,
+			want:   []string{"attributes.tier: Unsupported value"},
+		},
+		"Items": {
+			schema: dc,
+			attrs:  `{"fabric-size": 8, "pods": ["pod1", "rack2", "pod3"]}`,
+			want:   []string{"attributes.pods: Too many", "attributes.pods[1]"},
+		},
+		"WrongType": {
+			schema: dc,
+			attrs:  `{"fabric-size": "8"}`,
+			want:   []string{"must be of type integer"},
+		},
+		"UnknownForbidden": {
+			schema: strict,
+			attrs:  `{"fabric-size": 8}`,
+			want:   []string{"attributes.fabric-size: Forbidden"},
+		},
+		"ExclusiveMinimum": {
+			schema: strict,
+			attrs:  `{"peering-asn": 0}`,
+			want:   []string{"must be greater than 0"},
+		},
+		"Malformed": {
+			schema: strict,
+			attrs:  `{`,
+			want:   []string{errDecodeAttributes},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var s *apiextensionsv1.JSONSchemaProps
+			if tc.schema != "" {
+				s = schema(t, tc.schema)
+			}
+			err := Validate(s, attrs(tc.attrs))
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate(): unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate(): want error containing %q, got nil", tc.want)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate(): error %q does not contain %q", err, w)
+				}
+			}
+		})
+	}
+}
@@ -27,6 +27,13 @@ import (
 
 // Setup package controllers.
 func Setup(mgr ctrl.Manager, option controller.Options, nddcopts *shared.NddControllerOptions) error {
+	if nddcopts.ConfigMaps == nil {
+		cms, err := shared.NewConfigMapCache(mgr, configMapNamespaces(nddcopts))
+		if err != nil {
+			return err
+		}
+		nddcopts.ConfigMaps = cms
+	}
 	for _, setup := range []func(ctrl.Manager, controller.Options, *shared.NddControllerOptions) error{
 		organization.Setup,
 		deployment.Setup,
@@ -38,3 +45,15 @@ func Setup(mgr ctrl.Manager, option controller.Options, nddcopts *shared.NddCont
 
 	return nil
 }
+
+// configMapNamespaces returns the namespaces of the registry ConfigMaps: the
+// ndd namespace with the id tables, the watched namespaces and the publication
+// namespaces. It returns all namespaces when the manager watches all
+// namespaces.
+func configMapNamespaces(nddcopts *shared.NddControllerOptions) []string {
+	if len(nddcopts.WatchNamespaces) == 0 {
+		return nil
+	}
+	namespaces := append([]string{nddcopts.Namespace}, nddcopts.WatchNamespaces...)
+	return append(namespaces, nddcopts.PublicationNamespaces...)
+}
@@ -0,0 +1,636 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/sharding"
+	"github.com/yndd/nddr-org-registry/internal/shared"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newNamespace creates a namespace per test, organizations are namespace
+// local so tests do not see each other.
+func newNamespace(t *testing.T) string {
+	t.Helper()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-")),
+	}}
+	if err := k8sClient.Create(context.Background(), ns); err != nil {
+		t.Fatalf("cannot create namespace: %v", err)
+	}
+	return ns.GetName()
+}
+
+func registerList(r map[string]string) []*nddov1.Register {
+	kinds := make([]string, 0, len(r))
+	for kind := range r {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	l := make([]*nddov1.Register, 0, len(r))
+	for _, kind := range kinds {
+		l = append(l, &nddov1.Register{Kind: utils.StringPtr(kind), Name: utils.StringPtr(r[kind])})
+	}
+	return l
+}
+
+func newOrganization(namespace, name string, register map[string]string) *orgv1alpha1.Organization {
+	return &orgv1alpha1.Organization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: orgv1alpha1.OrganizationSpec{
+			Properties: orgv1alpha1.OrganizationProperties{
+				Description: utils.StringPtr("test organization"),
+				Register:    registerList(register),
+			},
+		},
+	}
+}
+
+func newDeployment(namespace, name string, register map[string]string) *orgv1alpha1.Deployment {
+	return &orgv1alpha1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: orgv1alpha1.DeploymentSpec{
+			Properties: orgv1alpha1.DeploymentProperties{
+				Description: utils.StringPtr("test deployment"),
+				AdminState:  utils.StringPtr("enable"),
+				Kind:        utils.StringPtr("dc"),
+				Register:    registerList(register),
+			},
+		},
+	}
+}
+
+func create(t *testing.T, obj client.Object) {
+	t.Helper()
+	if err := k8sClient.Create(context.Background(), obj); err != nil {
+		t.Fatalf("cannot create %s: %v", obj.GetName(), err)
+	}
+}
+
+// update applies mutate to the latest version of obj until it is stored.
+func update(t *testing.T, obj client.Object, mutate func()) {
+	t.Helper()
+	eventually(t, func() error {
+		if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		mutate()
+		return k8sClient.Update(context.Background(), obj)
+	})
+}
+
+// eventually retries check until it succeeds or the timeout expires.
+func eventually(t *testing.T, check func() error) {
+	t.Helper()
+	deadline := time.Now().Add(eventuallyTimeout)
+	for {
+		err := check()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s: %v", eventuallyTimeout, err)
+		}
+		time.Sleep(eventuallyInterval)
+	}
+}
+
+// hasState returns a check that the status of cr has the supplied state and
+// register.
+func hasState(cr interface {
+	client.Object
+	GetStatus() string
+	GetReason() string
+	GetStateRegister() map[string]string
+}, status, reason string, register map[string]string) func() error {
+	return func() error {
+		if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cr), cr); err != nil {
+			return err
+		}
+		if cr.GetStatus() != status || cr.GetReason() != reason {
+			return fmt.Errorf("want status %q reason %q, got status %q reason %q",
+				status, reason, cr.GetStatus(), cr.GetReason())
+		}
+		if got := cr.GetStateRegister(); !reflect.DeepEqual(got, register) {
+			return fmt.Errorf("want register %v, got %v", register, got)
+		}
+		return nil
+	}
+}
+
+var orgRegister = map[string]string{
+	"ipam": "nokia-ipam",
+	"as":   "nokia-as",
+	"ni":   "nokia-ni",
+	"vlan": "nokia-vlan",
+}
+
+func TestOrganizationCreate(t *testing.T) {
+	ns := newNamespace(t)
+	org := newOrganization(ns, "nokia", orgRegister)
+	create(t, org)
+
+	eventually(t, hasState(org, "up", "", orgRegister))
+}
+
+func TestDeploymentInheritsOrganizationRegister(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "nokia.dc1", nil)
+	create(t, dep)
+
+	eventually(t, hasState(dep, "up", "", orgRegister))
+}
+
+func TestDeploymentOverridesOrganizationRegister(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "nokia.dc1", map[string]string{
+		"ipam": "dc1-ipam",
+		"esi":  "dc1-esi",
+	})
+	create(t, dep)
+
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "dc1-ipam",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "nokia-vlan",
+		"esi":  "dc1-esi",
+	}))
+}
+
+func TestDeploymentAdminStateDisable(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "nokia.dc1", nil)
+	create(t, dep)
+	eventually(t, hasState(dep, "up", "", orgRegister))
+
+	update(t, dep, func() { dep.Spec.Properties.AdminState = utils.StringPtr("disable") })
+	eventually(t, hasState(dep, "down", "admin state disabled", map[string]string{}))
+
+	update(t, dep, func() { dep.Spec.Properties.AdminState = utils.StringPtr("enable") })
+	eventually(t, hasState(dep, "up", "", orgRegister))
+}
+
+func TestOrganizationDelete(t *testing.T) {
+	ns := newNamespace(t)
+	org := newOrganization(ns, "nokia", orgRegister)
+	create(t, org)
+	dep := newDeployment(ns, "nokia.dc1", nil)
+	create(t, dep)
+	eventually(t, hasState(dep, "up", "", orgRegister))
+
+	if err := k8sClient.Delete(context.Background(), org); err != nil {
+		t.Fatalf("cannot delete organization: %v", err)
+	}
+	eventually(t, hasState(dep, "down", "organization not found", map[string]string{}))
+}
+
+func TestOrganizationRegisterChangePropagates(t *testing.T) {
+	ns := newNamespace(t)
+	org := newOrganization(ns, "nokia", orgRegister)
+	create(t, org)
+	dep := newDeployment(ns, "nokia.dc1", map[string]string{"vlan": "dc1-vlan"})
+	create(t, dep)
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "nokia-ipam",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "dc1-vlan",
+	}))
+
+	changed := map[string]string{
+		"ipam": "nokia-ipam2",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "nokia-vlan",
+		"rt":   "nokia-rt",
+	}
+	update(t, org, func() { org.Spec.Properties.Register = registerList(changed) })
+
+	// the organization watch of the deployment controller enqueues dc1
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "nokia-ipam2",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "dc1-vlan",
+		"rt":   "nokia-rt",
+	}))
+}
+
+func TestDeploymentOrganizationRef(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "dc1", nil)
+	dep.Spec.Properties.OrganizationRef = &orgv1alpha1.OrganizationReference{Name: "nokia"}
+	create(t, dep)
+
+	eventually(t, hasState(dep, "up", "", orgRegister))
+}
+
+func TestDeploymentOrganizationRefMismatch(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "acme.dc1", nil)
+	dep.Spec.Properties.OrganizationRef = &orgv1alpha1.OrganizationReference{Name: "nokia"}
+	create(t, dep)
+
+	eventually(t, hasState(dep, "down", "organization reference mismatch", map[string]string{}))
+}
+
+func TestRegisterPolicy(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	create(t, &orgv1alpha1.RegisterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "regions"},
+		Spec: orgv1alpha1.RegisterPolicySpec{Properties: orgv1alpha1.RegisterPolicyProperties{
+			Organization: "nokia",
+			Rules: []*orgv1alpha1.RegisterPolicyRule{{
+				Name:     "emea-wan",
+				Match:    orgv1alpha1.RegisterPolicyMatch{Kind: utils.StringPtr("wan"), Region: utils.StringPtr("emea")},
+				Register: registerList(map[string]string{"ipam": "emea-wan", "vlan": "emea-vlan"}),
+			}},
+		}},
+	})
+	dep := newDeployment(ns, "nokia.wan1", map[string]string{"vlan": "wan1-vlan"})
+	dep.Spec.Properties.Kind = utils.StringPtr("wan")
+	dep.Spec.Properties.Region = utils.StringPtr("emea")
+	create(t, dep)
+
+	// the rule overrides the organization, the deployment overrides the rule
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "emea-wan",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "wan1-vlan",
+	}))
+	eventually(t, func() error {
+		if got := dep.GetStateRegisterPolicy(); got == nil || got.Policy != "regions" || got.Rule != "emea-wan" {
+			return fmt.Errorf("want rule regions/emea-wan, got %v", got)
+		}
+		return nil
+	})
+
+	update(t, dep, func() { dep.Spec.Properties.Region = utils.StringPtr("apac") })
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "nokia-ipam",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "wan1-vlan",
+	}))
+}
+
+func TestDeploymentClass(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	class := &orgv1alpha1.DeploymentClass{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "standard-dc"},
+		Spec: orgv1alpha1.DeploymentClassSpec{Properties: orgv1alpha1.DeploymentClassProperties{
+			Kind:     utils.StringPtr("dc"),
+			Register: registerList(map[string]string{"ipam": "class-ipam", "vlan": "class-vlan"}),
+		}},
+	}
+	create(t, class)
+	dep := newDeployment(ns, "nokia.dc1", map[string]string{"vlan": "dc1-vlan"})
+	dep.Spec.Properties.AdminState = nil
+	dep.Spec.Properties.Kind = nil
+	dep.Spec.Properties.DeploymentClassName = utils.StringPtr("standard-dc")
+	create(t, dep)
+
+	// the class overrides the organization, the deployment overrides the class
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "class-ipam",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"vlan": "dc1-vlan",
+	}))
+	if got := dep.GetKind(); got != "dc" {
+		t.Errorf("GetKind(): want the kind of the class, got %q", got)
+	}
+
+	// a class change is applied to the deployments of the class
+	update(t, class, func() { class.Spec.Properties.AdminState = utils.StringPtr("disable") })
+	eventually(t, hasState(dep, "down", "admin state disabled", map[string]string{}))
+
+	if err := k8sClient.Delete(context.Background(), class); err != nil {
+		t.Fatalf("cannot delete deployment class: %v", err)
+	}
+	eventually(t, hasState(dep, "down", "deployment class not found", map[string]string{}))
+}
+
+func TestDeploymentKind(t *testing.T) {
+	ns := newNamespace(t)
+	create(t, newOrganization(ns, "nokia", orgRegister))
+	dep := newDeployment(ns, "nokia.edge1", map[string]string{"vlan": "edge1-vlan"})
+	dep.Spec.Properties.Kind = utils.StringPtr("edge")
+	dep.Spec.Properties.Attributes = &apiextensionsv1.JSON{Raw: []byte(`{"uplinks": "two"}`)}
+	create(t, dep)
+
+	// kinds other than dc and wan need a definition
+	eventually(t, hasState(dep, "down", "deployment kind not defined", map[string]string{}))
+
+	minimum := float64(1)
+	kind := &orgv1alpha1.DeploymentKindDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge"},
+		Spec: orgv1alpha1.DeploymentKindDefinitionSpec{Properties: orgv1alpha1.DeploymentKindDefinitionProperties{
+			Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:     "object",
+				Required: []string{"uplinks"},
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"uplinks": {Type: "integer", Minimum: &minimum},
+				},
+			},
+			Register:          registerList(map[string]string{"ipam": "edge-ipam", "esi": "edge-esi"}),
+			CriticalRegisters: []string{"esi"},
+		}},
+	}
+	create(t, kind)
+	t.Cleanup(func() { _ = k8sClient.Delete(context.Background(), kind) })
+
+	eventually(t, hasState(dep, "down", "attributes invalid", map[string]string{}))
+
+	update(t, dep, func() {
+		dep.Spec.Properties.Attributes = &apiextensionsv1.JSON{Raw: []byte(`{"uplinks": 2}`)}
+	})
+
+	// the kind overrides the organization, the deployment overrides the kind
+	eventually(t, hasState(dep, "up", "", map[string]string{
+		"ipam": "edge-ipam",
+		"as":   "nokia-as",
+		"ni":   "nokia-ni",
+		"esi":  "edge-esi",
+		"vlan": "edge1-vlan",
+	}))
+	if got := dep.GetStateCriticalRegisters(); !reflect.DeepEqual(got, []string{"esi"}) {
+		t.Errorf("GetStateCriticalRegisters(): want [esi], got %v", got)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	ns := newNamespace(t)
+	nokia := newOrganization(ns, "nokia", orgRegister)
+	acme := newOrganization(ns, "acme", orgRegister)
+	create(t, nokia)
+	create(t, acme)
+	deps := []*orgv1alpha1.Deployment{
+		newDeployment(ns, "nokia.dc1", nil),
+		newDeployment(ns, "nokia.dc2", nil),
+		newDeployment(ns, "acme.dc1", nil),
+	}
+	for _, dep := range deps {
+		create(t, dep)
+	}
+
+	// organization ids are unique in the cluster
+	eventually(t, func() error {
+		for _, org := range []*orgv1alpha1.Organization{nokia, acme} {
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(org), org); err != nil {
+				return err
+			}
+			if org.GetStateOrganizationID() == 0 {
+				return fmt.Errorf("organization %s has no id", org.GetName())
+			}
+		}
+		if nokia.GetStateOrganizationID() == acme.GetStateOrganizationID() {
+			return fmt.Errorf("organizations share the id %d", nokia.GetStateOrganizationID())
+		}
+		return nil
+	})
+
+	// deployment ids are unique in the organization
+	wantOrgID := map[string]int64{
+		"nokia.dc1": nokia.GetStateOrganizationID(),
+		"nokia.dc2": nokia.GetStateOrganizationID(),
+		"acme.dc1":  acme.GetStateOrganizationID(),
+	}
+	eventually(t, func() error {
+		got := make(map[int64]string)
+		for _, dep := range deps {
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(dep), dep); err != nil {
+				return err
+			}
+			if w := wantOrgID[dep.GetName()]; dep.GetStateOrganizationID() != w {
+				return fmt.Errorf("deployment %s: want organization id %d, got %d", dep.GetName(), w, dep.GetStateOrganizationID())
+			}
+			if dep.GetStateDeploymentID() == 0 {
+				return fmt.Errorf("deployment %s has no id", dep.GetName())
+			}
+			if dep.GetOrganizationName() == "nokia" {
+				if other, ok := got[dep.GetStateDeploymentID()]; ok {
+					return fmt.Errorf("deployments %s and %s share the id %d", dep.GetName(), other, dep.GetStateDeploymentID())
+				}
+				got[dep.GetStateDeploymentID()] = dep.GetName()
+			}
+		}
+		return nil
+	})
+
+	// the ids are kept when other deployments come and go
+	id := deps[1].GetStateDeploymentID()
+	if err := k8sClient.Delete(context.Background(), deps[0]); err != nil {
+		t.Fatalf("cannot delete deployment: %v", err)
+	}
+	dc3 := newDeployment(ns, "nokia.dc3", nil)
+	create(t, dc3)
+	eventually(t, func() error {
+		if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(dc3), dc3); err != nil {
+			return err
+		}
+		if dc3.GetStateDeploymentID() == 0 || dc3.GetStateDeploymentID() == id {
+			return fmt.Errorf("want a free id for nokia.dc3, got %d", dc3.GetStateDeploymentID())
+		}
+		return nil
+	})
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(deps[1]), deps[1]); err != nil {
+		t.Fatalf("cannot get deployment: %v", err)
+	}
+	if got := deps[1].GetStateDeploymentID(); got != id {
+		t.Errorf("nokia.dc2: want the id %d to be kept, got %d", id, got)
+	}
+}
+
+func TestDerived(t *testing.T) {
+	ns := newNamespace(t)
+	org := newOrganization(ns, "nokia", orgRegister)
+	asn := int64(65000)
+	org.Spec.Properties.ASN = &asn
+	org.Spec.Properties.DerivedTemplates = map[string]string{
+		"rt-base":    "{{.OrgASN}}:{{.DeploymentID}}",
+		"vrf-prefix": "{{.Organization}}-{{upper .Deployment}}",
+	}
+	create(t, org)
+	dep := newDeployment(ns, "nokia.dc1", nil)
+	create(t, dep)
+
+	eventually(t, func() error {
+		if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(dep), dep); err != nil {
+			return err
+		}
+		if dep.GetStateDeploymentID() == 0 {
+			return fmt.Errorf("deployment %s has no id", dep.GetName())
+		}
+		want := map[string]string{
+			"rt-base":    fmt.Sprintf("65000:%d", dep.GetStateDeploymentID()),
+			"vrf-prefix": "nokia-DC1",
+		}
+		if got := dep.GetStateDerived(); !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("want derived %v, got %v", want, got)
+		}
+		return nil
+	})
+
+	// a template that cannot be rendered takes the deployment down
+	update(t, org, func() {
+		org.Spec.Properties.DerivedTemplates["esi-prefix"] = "{{.Register.esi}}"
+	})
+	eventually(t, hasState(dep, "down", "derived identifiers invalid", map[string]string{}))
+	if got := dep.GetStateDerived(); len(got) != 0 {
+		t.Errorf("want no derived identifiers, got %v", got)
+	}
+}
+
+func TestConfigMap(t *testing.T) {
+	ns := newNamespace(t)
+	published := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns + "-published"}}
+	create(t, published)
+	org := newOrganization(ns, "nokia", orgRegister)
+	org.Spec.Properties.ConfigMap = &orgv1alpha1.ConfigMapPublication{}
+	create(t, org)
+	dep := newDeployment(ns, "nokia.dc1", nil)
+	create(t, dep)
+
+	// hasConfigMap returns a check that the ConfigMap is published for dc1
+	hasConfigMap := func(key client.ObjectKey, owned bool) func() error {
+		return func() error {
+			cm := &corev1.ConfigMap{}
+			if err := k8sClient.Get(context.Background(), key, cm); err != nil {
+				return err
+			}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(dep), dep); err != nil {
+				return err
+			}
+			want := map[string]string{
+				"organization":    "nokia",
+				"deployment":      "dc1",
+				"kind":            "dc",
+				"region":          "",
+				"status":          "up",
+				"reason":          "",
+				"organization-id": fmt.Sprint(dep.GetStateOrganizationID()),
+				"deployment-id":   fmt.Sprint(dep.GetStateDeploymentID()),
+			}
+			for kind, name := range orgRegister {
+				want["register."+kind] = name
+			}
+			if !reflect.DeepEqual(cm.Data, want) {
+				return fmt.Errorf("want data %v, got %v", want, cm.Data)
+			}
+			if owned != (len(cm.GetOwnerReferences()) == 1 && cm.GetOwnerReferences()[0].UID == dep.GetUID()) {
+				return fmt.Errorf("want owned by the deployment %t, got owners %v", owned, cm.GetOwnerReferences())
+			}
+			if ref := dep.GetStateConfigMap(); ref == nil || ref.Namespace != key.Namespace || ref.Name != key.Name {
+				return fmt.Errorf("want status configmap %s, got %v", key, ref)
+			}
+			return nil
+		}
+	}
+	// isDeleted returns a check that the ConfigMap does not exist
+	isDeleted := func(key client.ObjectKey) func() error {
+		return func() error {
+			err := k8sClient.Get(context.Background(), key, &corev1.ConfigMap{})
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("want configmap %s deleted, got %v", key, err)
+		}
+	}
+
+	local := client.ObjectKey{Namespace: ns, Name: "nokia.dc1"}
+	eventually(t, hasConfigMap(local, true))
+
+	// the ConfigMap moves with the namespace of the organization
+	other := client.ObjectKey{Namespace: published.GetName(), Name: ns + ".nokia.dc1"}
+	update(t, org, func() { org.Spec.Properties.ConfigMap.Namespace = published.GetName() })
+	eventually(t, hasConfigMap(other, false))
+	eventually(t, isDeleted(local))
+
+	// a ConfigMap in another namespace is deleted with the deployment
+	if err := k8sClient.Delete(context.Background(), dep); err != nil {
+		t.Fatalf("cannot delete deployment: %v", err)
+	}
+	eventually(t, isDeleted(other))
+}
+
+// TestShardedRegistryLookup looks up an organization of another shard. The
+// cache of a sharded replica misses it, the registry of the replica is served
+// from the unsharded cache the start command creates for the apis.
+func TestShardedRegistryLookup(t *testing.T) {
+	ns := newNamespace(t)
+	org := newOrganization(ns, "nokia", orgRegister)
+	org.SetLabels(map[string]string{sharding.LabelShard: "replica-1"})
+	create(t, org)
+	eventually(t, hasState(org, "up", "", orgRegister))
+
+	shard, err := shared.NewCluster(k8sManager, shared.NewOrganizationCache([]string{ns}, sharding.Selector("replica-0", labels.Everything())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := shared.NewCluster(k8sManager, shared.NewOrganizationCache([]string{ns}, labels.Everything()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := registry.New(registry.WithClient(api.GetClient()), registry.WithInformers(api.GetCache()))
+	eventually(t, func() error {
+		got, err := reg.GetRegisterByName(context.Background(), ns, "nokia")
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, orgRegister) {
+			return fmt.Errorf("want register %v, got %v", orgRegister, got)
+		}
+		return nil
+	})
+
+	// the shard cache is synced once its informer serves a lookup
+	eventually(t, func() error {
+		err := shard.GetClient().Get(context.Background(), client.ObjectKeyFromObject(org), &orgv1alpha1.Organization{})
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("want not found from the cache of replica-0, got %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/meta"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/shared"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// labels of the published ConfigMaps
+	labelManagedBy           = shared.LabelManagedBy
+	labelDeploymentNamespace = "org.nddr.yndd.io/deployment-namespace"
+	labelDeploymentName      = "org.nddr.yndd.io/deployment"
+	managedBy                = shared.ManagedBy
+
+	// errors
+	errPublishConfigMap  = "cannot publish configmap"
+	errGetConfigMap      = "cannot get configmap"
+	errCreateConfigMap   = "cannot create configmap"
+	errUpdateConfigMap   = "cannot update configmap"
+	errDeleteConfigMap   = "cannot delete configmap"
+	errConfigMapNotOwned = "configmap %s/%s exists and is not published for deployment %s/%s"
+)
+
+// configMapOf returns the ConfigMap the deployment is published in, nil when
+// its organization does not publish ConfigMaps.
+func configMapOf(org orgv1alpha1.Org, cr orgv1alpha1.Dp) *orgv1alpha1.ConfigMapReference {
+	if org == nil || org.GetConfigMapPublication() == nil {
+		return nil
+	}
+	namespace := org.GetConfigMapPublication().Namespace
+	if namespace == "" || namespace == cr.GetNamespace() {
+		return &orgv1alpha1.ConfigMapReference{Namespace: cr.GetNamespace(), Name: cr.GetName()}
+	}
+	// deployments of several namespaces can be published in the namespace
+	return &orgv1alpha1.ConfigMapReference{Namespace: namespace, Name: cr.GetNamespace() + "." + cr.GetName()}
+}
+
+// configMapData returns the data of the ConfigMap of the deployment, the keys
+// are flat so shell scripts can read them with a jsonpath.
+func configMapData(cr orgv1alpha1.Dp) map[string]string {
+	data := map[string]string{
+		"organization":    cr.GetOrganizationName(),
+		"deployment":      cr.GetDeploymentName(),
+		"kind":            cr.GetKind(),
+		"region":          cr.GetRegion(),
+		"status":          cr.GetStatus(),
+		"reason":          cr.GetReason(),
+		"organization-id": strconv.FormatInt(cr.GetStateOrganizationID(), 10),
+		"deployment-id":   strconv.FormatInt(cr.GetStateDeploymentID(), 10),
+	}
+	for kind, name := range cr.GetStateRegister() {
+		data["register."+kind] = name
+	}
+	if aas := cr.GetStateAddressAllocationStrategy(); aas != nil {
+		if aas.GatewayAllocation != nil {
+			data["address-allocation-strategy.gateway-allocation"] = aas.GetGatewayAllocation()
+		}
+		if aas.InfraItfcePrefixLengthIpv4 != nil {
+			data["address-allocation-strategy.infra-interface-prefixlength-ipv4"] = strconv.FormatUint(uint64(aas.GetInfraItfcePrefixLengthIpv4()), 10)
+		}
+		if aas.InfraItfcePrefixLengthIpv6 != nil {
+			data["address-allocation-strategy.infra-interface-prefixlength-ipv6"] = strconv.FormatUint(uint64(aas.GetInfraItfcePrefixLengthIpv6()), 10)
+		}
+	}
+	for name, value := range cr.GetStateDerived() {
+		data["derived."+name] = value
+	}
+	return data
+}
+
+func configMapLabels(cr orgv1alpha1.Dp) map[string]string {
+	return map[string]string{
+		labelManagedBy:           managedBy,
+		labelDeploymentNamespace: cr.GetNamespace(),
+		labelDeploymentName:      cr.GetName(),
+	}
+}
+
+// publishedFor returns true if the ConfigMap is published for the deployment.
+func publishedFor(cm *corev1.ConfigMap, cr orgv1alpha1.Dp) bool {
+	l := cm.GetLabels()
+	return l[labelManagedBy] == managedBy &&
+		l[labelDeploymentNamespace] == cr.GetNamespace() &&
+		l[labelDeploymentName] == cr.GetName()
+}
+
+// publishConfigMap keeps the ConfigMap of the deployment in sync with its
+// status. A ConfigMap the deployment is no longer published in is deleted.
+func (r *application) publishConfigMap(ctx context.Context, org orgv1alpha1.Org, cr orgv1alpha1.Dp) error {
+	want := configMapOf(org, cr)
+	if prev := cr.GetStateConfigMap(); prev != nil && (want == nil || *prev != *want) {
+		if err := r.deleteConfigMap(ctx, cr, *prev); err != nil {
+			return err
+		}
+		cr.SetStateConfigMap(nil)
+	}
+	if want == nil {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := r.reader.Get(ctx, types.NamespacedName{Namespace: want.Namespace, Name: want.Name}, cm)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, errGetConfigMap)
+	}
+	exists := err == nil
+	if exists && !publishedFor(cm, cr) {
+		return fmt.Errorf(errConfigMapNotOwned, want.Namespace, want.Name, cr.GetNamespace(), cr.GetName())
+	}
+
+	desired := cm.DeepCopy()
+	desired.SetNamespace(want.Namespace)
+	desired.SetName(want.Name)
+	desired.SetLabels(configMapLabels(cr))
+	desired.Data = configMapData(cr)
+	desired.SetOwnerReferences(nil)
+	// owner references cannot cross namespaces, a ConfigMap in another
+	// namespace is deleted with the deployment instead
+	if want.Namespace == cr.GetNamespace() {
+		meta.AddOwnerReference(desired, meta.AsController(meta.TypedReferenceTo(cr, orgv1alpha1.DeploymentGroupVersionKind)))
+	}
+
+	switch {
+	case !exists:
+		if err := r.client.Create(ctx, desired); err != nil {
+			return errors.Wrap(err, errCreateConfigMap)
+		}
+	case !reflect.DeepEqual(cm.Data, desired.Data) || !reflect.DeepEqual(cm.GetLabels(), desired.GetLabels()) ||
+		!reflect.DeepEqual(cm.GetOwnerReferences(), desired.GetOwnerReferences()):
+		if err := r.client.Update(ctx, desired); err != nil {
+			return errors.Wrap(err, errUpdateConfigMap)
+		}
+	}
+	cr.SetStateConfigMap(want)
+	return nil
+}
+
+// deleteConfigMap deletes the ConfigMap the deployment was published in, a
+// ConfigMap that is not published for the deployment is left alone.
+func (r *application) deleteConfigMap(ctx context.Context, cr orgv1alpha1.Dp, ref orgv1alpha1.ConfigMapReference) error {
+	cm := &corev1.ConfigMap{}
+	if err := r.reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, errGetConfigMap)
+	}
+	if !publishedFor(cm, cr) {
+		return nil
+	}
+	uid := cm.GetUID()
+	err := r.client.Delete(ctx, cm, client.Preconditions{UID: &uid})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, errDeleteConfigMap)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yndd/ndd-runtime/pkg/event"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+)
+
+const (
+	// status reasons
+	statusReasonOrganizationNotFound     = registry.StatusReasonOrganizationNotFound
+	statusReasonAdminStateDisabled       = registry.StatusReasonAdminStateDisabled
+	statusReasonDeploymentClassNotFound  = registry.StatusReasonDeploymentClassNotFound
+	statusReasonDeploymentKindNotDefined = registry.StatusReasonDeploymentKindNotDefined
+	statusReasonAttributesInvalid        = registry.StatusReasonAttributesInvalid
+	statusReasonDerivedInvalid           = registry.StatusReasonDerivedInvalid
+
+	// event reasons
+	reasonRegisterChanged      event.Reason = "RegisterChanged"
+	reasonRegisterInvalid      event.Reason = "RegisterInvalid"
+	reasonOrganizationNotFound event.Reason = "OrganizationNotFound"
+	reasonOrganizationFound    event.Reason = "OrganizationFound"
+	reasonAdminStateDisabled   event.Reason = "AdminStateDisabled"
+	reasonAdminStateEnabled    event.Reason = "AdminStateEnabled"
+	reasonPolicyMatched        event.Reason = "RegisterPolicyMatched"
+	reasonPolicyUnmatched      event.Reason = "RegisterPolicyUnmatched"
+	reasonClassNotFound        event.Reason = "DeploymentClassNotFound"
+	reasonClassFound           event.Reason = "DeploymentClassFound"
+	reasonKindNotDefined       event.Reason = "DeploymentKindNotDefined"
+	reasonKindDefined          event.Reason = "DeploymentKindDefined"
+	reasonAttributesInvalid    event.Reason = "AttributesInvalid"
+	reasonAttributesValid      event.Reason = "AttributesValid"
+	reasonDerivedInvalid       event.Reason = "DerivedInvalid"
+	reasonDerivedValid         event.Reason = "DerivedValid"
+
+	noRegister = "<none>"
+)
+
+// observedState is the part of the deployment status events are derived
+// from.
+type observedState struct {
+	reason   string
+	register map[string]string
+	// policy is the matched register policy rule as policy/rule
+	policy string
+}
+
+func observe(cr orgv1alpha1.Dp) observedState {
+	register := make(map[string]string, len(cr.GetStateRegister()))
+	for kind, name := range cr.GetStateRegister() {
+		register[kind] = name
+	}
+	policy := ""
+	if p := cr.GetStateRegisterPolicy(); p != nil {
+		policy = p.Policy + "/" + p.Rule
+	}
+	return observedState{
+		reason:   cr.GetReason(),
+		register: register,
+		policy:   policy,
+	}
+}
+
+// recordEvents emits the events of the transition from the previously
+// observed status to the current status of the deployment. Events are only
+// emitted when the status changed, a steady state reconcile emits nothing.
+func (r *application) recordEvents(cr orgv1alpha1.Dp, prev observedState) {
+	for _, e := range transitionEvents(cr, prev) {
+		r.record.Event(cr, e)
+	}
+}
+
+func transitionEvents(cr orgv1alpha1.Dp, prev observedState) []event.Event {
+	var events []event.Event
+	now := observe(cr)
+
+	switch {
+	case now.reason == statusReasonOrganizationNotFound && prev.reason != statusReasonOrganizationNotFound:
+		events = append(events, event.Warning(reasonOrganizationNotFound,
+			fmt.Errorf("organization %s not found", cr.GetOrganizationName())))
+	case now.reason != statusReasonOrganizationNotFound && prev.reason == statusReasonOrganizationNotFound:
+		events = append(events, event.Normal(reasonOrganizationFound,
+			fmt.Sprintf("organization %s found", cr.GetOrganizationName())))
+	}
+
+	switch {
+	case now.reason == statusReasonDeploymentClassNotFound && prev.reason != statusReasonDeploymentClassNotFound:
+		events = append(events, event.Warning(reasonClassNotFound,
+			fmt.Errorf("deployment class %s not found", cr.GetDeploymentClassName())))
+	case now.reason != statusReasonDeploymentClassNotFound && prev.reason == statusReasonDeploymentClassNotFound:
+		events = append(events, event.Normal(reasonClassFound,
+			fmt.Sprintf("deployment class %s found", cr.GetDeploymentClassName())))
+	}
+
+	switch {
+	case now.reason == statusReasonDeploymentKindNotDefined && prev.reason != statusReasonDeploymentKindNotDefined:
+		events = append(events, event.Warning(reasonKindNotDefined,
+			fmt.Errorf("deployment kind %s not defined", cr.GetKind())))
+	case now.reason != statusReasonDeploymentKindNotDefined && prev.reason == statusReasonDeploymentKindNotDefined:
+		events = append(events, event.Normal(reasonKindDefined,
+			fmt.Sprintf("deployment kind %s defined", cr.GetKind())))
+	}
+
+	switch {
+	case now.reason == statusReasonAttributesInvalid && prev.reason != statusReasonAttributesInvalid:
+		events = append(events, event.Warning(reasonAttributesInvalid,
+			fmt.Errorf("attributes do not match the schema of deployment kind %s", cr.GetKind())))
+	case now.reason != statusReasonAttributesInvalid && prev.reason == statusReasonAttributesInvalid:
+		events = append(events, event.Normal(reasonAttributesValid,
+			fmt.Sprintf("attributes match the schema of deployment kind %s", cr.GetKind())))
+	}
+
+	switch {
+	case now.reason == statusReasonDerivedInvalid && prev.reason != statusReasonDerivedInvalid:
+		events = append(events, event.Warning(reasonDerivedInvalid,
+			fmt.Errorf("derived templates of organization %s cannot be rendered", cr.GetOrganizationName())))
+	case now.reason != statusReasonDerivedInvalid && prev.reason == statusReasonDerivedInvalid:
+		events = append(events, event.Normal(reasonDerivedValid,
+			fmt.Sprintf("derived templates of organization %s rendered", cr.GetOrganizationName())))
+	}
+
+	switch {
+	case now.reason == statusReasonAdminStateDisabled && prev.reason != statusReasonAdminStateDisabled:
+		events = append(events, event.Normal(reasonAdminStateDisabled, "admin state disabled"))
+	case now.reason != statusReasonAdminStateDisabled && prev.reason == statusReasonAdminStateDisabled:
+		events = append(events, event.Normal(reasonAdminStateEnabled, "admin state enabled"))
+	}
+
+	switch {
+	case now.policy != "" && now.policy != prev.policy:
+		events = append(events, event.Normal(reasonPolicyMatched,
+			fmt.Sprintf("register policy rule %s matched", now.policy)))
+	case now.policy == "" && prev.policy != "":
+		events = append(events, event.Normal(reasonPolicyUnmatched,
+			fmt.Sprintf("register policy rule %s no longer matches", prev.policy)))
+	}
+
+	if changes := registerChanges(prev.register, now.register); len(changes) > 0 {
+		events = append(events, event.Normal(reasonRegisterChanged, strings.Join(changes, ", ")))
+		if len(now.register) > 0 {
+			if err := registry.ValidateRegister(now.register, cr.GetStateCriticalRegisters()...); err != nil {
+				events = append(events, event.Warning(reasonRegisterInvalid, err))
+			}
+		}
+	}
+	return events
+}
+
+// registerChanges returns the changes per register kind, sorted by kind.
+func registerChanges(prev, now map[string]string) []string {
+	kinds := make(map[string]struct{}, len(prev)+len(now))
+	for kind := range prev {
+		kinds[kind] = struct{}{}
+	}
+	for kind := range now {
+		kinds[kind] = struct{}{}
+	}
+
+	var changes []string
+	for kind := range kinds {
+		p, ok := prev[kind]
+		if !ok {
+			p = noRegister
+		}
+		n, ok := now[kind]
+		if !ok {
+			n = noRegister
+		}
+		if p != n {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", kind, p, n))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yndd/ndd-runtime/pkg/event"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegisterChanges(t *testing.T) {
+	cases := map[string]struct {
+		prev map[string]string
+		now  map[string]string
+		want []string
+	}{
+		"Unchanged": {
+			prev: map[string]string{"ipam": "nokia-ipam"},
+			now:  map[string]string{"ipam": "nokia-ipam"},
+			want: nil,
+		},
+		"Added": {
+			prev: nil,
+			now:  map[string]string{"ipam": "nokia-ipam", "as": "nokia-as"},
+			want: []string{"as: <none> -> nokia-as", "ipam: <none> -> nokia-ipam"},
+		},
+		"Removed": {
+			prev: map[string]string{"ipam": "nokia-ipam"},
+			now:  map[string]string{},
+			want: []string{"ipam: nokia-ipam -> <none>"},
+		},
+		"Changed": {
+			prev: map[string]string{"ipam": "nokia-ipam", "as": "nokia-as"},
+			now:  map[string]string{"ipam": "nokia-core", "as": "nokia-as"},
+			want: []string{"ipam: nokia-ipam -> nokia-core"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, registerChanges(tc.prev, tc.now)); diff != "" {
+				t.Errorf("registerChanges(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTransitionEvents(t *testing.T) {
+	full := map[string]string{"ipam": "nokia-ipam", "as": "nokia-as", "ni": "nokia-ni"}
+	policy := &orgv1alpha1.RegisterPolicyRuleReference{Policy: "regions", Rule: "core"}
+
+	type reasonOf struct {
+		Type   event.Type
+		Reason event.Reason
+	}
+	cases := map[string]struct {
+		prev     observedState
+		reason   string
+		register map[string]string
+		policy   *orgv1alpha1.RegisterPolicyRuleReference
+		want     []reasonOf
+	}{
+		"SteadyState": {
+			prev:     observedState{register: full, policy: "regions/core"},
+			register: full,
+			policy:   policy,
+			want:     nil,
+		},
+		"OrganizationNotFound": {
+			prev:   observedState{},
+			reason: statusReasonOrganizationNotFound,
+			want:   []reasonOf{{event.TypeWarning, reasonOrganizationNotFound}},
+		},
+		"OrganizationNotFoundRepeated": {
+			prev:   observedState{reason: statusReasonOrganizationNotFound},
+			reason: statusReasonOrganizationNotFound,
+			want:   nil,
+		},
+		"OrganizationFound": {
+			prev:     observedState{reason: statusReasonOrganizationNotFound},
+			register: full,
+			want: []reasonOf{
+				{event.TypeNormal, reasonOrganizationFound},
+				{event.TypeNormal, reasonRegisterChanged},
+			},
+		},
+		"ClassNotFound": {
+			prev:     observedState{register: full},
+			reason:   statusReasonDeploymentClassNotFound,
+			register: map[string]string{},
+			want: []reasonOf{
+				{event.TypeWarning, reasonClassNotFound},
+				{event.TypeNormal, reasonRegisterChanged},
+			},
+		},
+		"AdminStateEnabled": {
+			prev:     observedState{reason: statusReasonAdminStateDisabled},
+			register: full,
+			want: []reasonOf{
+				{event.TypeNormal, reasonAdminStateEnabled},
+				{event.TypeNormal, reasonRegisterChanged},
+			},
+		},
+		"PolicyMatched": {
+			prev:     observedState{register: full},
+			register: full,
+			policy:   policy,
+			want:     []reasonOf{{event.TypeNormal, reasonPolicyMatched}},
+		},
+		"PolicyUnmatched": {
+			prev:     observedState{register: full, policy: "regions/core"},
+			register: full,
+			want:     []reasonOf{{event.TypeNormal, reasonPolicyUnmatched}},
+		},
+		"RegisterInvalid": {
+			prev:     observedState{register: full},
+			register: map[string]string{"ipam": "nokia-ipam"},
+			want: []reasonOf{
+				{event.TypeNormal, reasonRegisterChanged},
+				{event.TypeWarning, reasonRegisterInvalid},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.region1"}}
+			if err := cr.InitializeResource(); err != nil {
+				t.Fatal(err)
+			}
+			cr.SetReason(tc.reason)
+			cr.SetStateRegister(tc.register)
+			cr.SetStateRegisterPolicy(tc.policy)
+
+			var got []reasonOf
+			for _, e := range transitionEvents(cr, tc.prev) {
+				got = append(got, reasonOf{e.Type, e.Reason})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("transitionEvents(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
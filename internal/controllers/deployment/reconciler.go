@@ -19,6 +19,7 @@ package deployment
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -26,23 +27,32 @@ import (
 	"github.com/yndd/ndd-runtime/pkg/event"
 	"github.com/yndd/ndd-runtime/pkg/logging"
 	"github.com/yndd/ndd-runtime/pkg/resource"
-	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
 	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
 	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/idalloc"
+	"github.com/yndd/nddr-org-registry/internal/pause"
 	"github.com/yndd/nddr-org-registry/internal/shared"
+	"github.com/yndd/nddr-org-registry/internal/tracing"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrlhandler "sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	// timers
 	reconcileTimeout = 1 * time.Minute
-	shortWait        = 5 * time.Second
-	veryShortWait    = 1 * time.Second
 	// errors
 	errUnexpectedResource = "unexpected deployment object"
 	errGetK8sResource     = "cannot get deployment resource"
+	errResolveDeployment  = "cannot resolve deployment"
 )
 
 // Setup adds a controller that reconciles infra.
@@ -51,21 +61,36 @@ func Setup(mgr ctrl.Manager, o controller.Options, nddcopts *shared.NddControlle
 	depfn := func() orgv1alpha1.Dp { return &orgv1alpha1.Deployment{} }
 	deplfn := func() orgv1alpha1.DpList { return &orgv1alpha1.DeploymentList{} }
 	orglfn := func() orgv1alpha1.OrgList { return &orgv1alpha1.OrganizationList{} }
+	rplfn := func() orgv1alpha1.RpList { return &orgv1alpha1.RegisterPolicyList{} }
+	dcfn := func() orgv1alpha1.Dc { return &orgv1alpha1.DeploymentClass{} }
+	dkdfn := func() orgv1alpha1.Dkd { return &orgv1alpha1.DeploymentKindDefinition{} }
 
-	r := managed.NewReconciler(mgr,
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+
+	r := managed.NewReconciler(shared.UnchangedStatusSkipper(mgr),
 		resource.ManagedKind(orgv1alpha1.DeploymentGroupVersionKind),
 		managed.WithLogger(nddcopts.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(nddcopts.Poll),
 		managed.WithApplogic(&application{
 			client: resource.ClientApplicator{
 				Client:     mgr.GetClient(),
 				Applicator: resource.NewAPIPatchingApplicator(mgr.GetClient()),
 			},
+			reader:     nddcopts.ConfigMaps,
 			log:        nddcopts.Logger.WithValues("applogic", name),
 			newDep:     depfn,
 			newOrgList: orglfn,
+			newRpList:  rplfn,
+			newDc:      dcfn,
+			newDkd:     dkdfn,
+			newDepList: deplfn,
+			ids:        idalloc.New(registry.MaxDeploymentID),
 			handler:    nddcopts.Handler,
+			record:     recorder,
+
+			crossNamespace: nddcopts.AllowCrossNamespaceOrganizations,
 		}),
-		managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		managed.WithRecorder(shared.RepeatedWarningFilter(recorder, shared.WarningWindow)),
 	)
 
 	orgHandler := &EnqueueRequestForAllOrganizations{
@@ -74,27 +99,90 @@ func Setup(mgr ctrl.Manager, o controller.Options, nddcopts *shared.NddControlle
 		ctx:        context.Background(),
 		newDepList: deplfn,
 		handler:    nddcopts.Handler,
+
+		crossNamespace: nddcopts.AllowCrossNamespaceOrganizations,
+	}
+
+	policyHandler := &EnqueueRequestForAllRegisterPolicies{
+		client:     mgr.GetClient(),
+		log:        nddcopts.Logger,
+		ctx:        context.Background(),
+		newDepList: deplfn,
+		handler:    nddcopts.Handler,
+
+		crossNamespace: nddcopts.AllowCrossNamespaceOrganizations,
 	}
 
+	classHandler := &EnqueueRequestForAllDeploymentClasses{
+		client:     mgr.GetClient(),
+		log:        nddcopts.Logger,
+		ctx:        context.Background(),
+		newDepList: deplfn,
+		handler:    nddcopts.Handler,
+	}
+
+	configMapHandler := &EnqueueRequestForPublishedConfigMaps{
+		log:     nddcopts.Logger,
+		handler: nddcopts.Handler,
+	}
+
+	kindHandler := &EnqueueRequestForAllDeploymentKinds{
+		client:     mgr.GetClient(),
+		log:        nddcopts.Logger,
+		ctx:        context.Background(),
+		newDepList: deplfn,
+		handler:    nddcopts.Handler,
+	}
+
+	// the published ConfigMaps have no generation, their watches are not
+	// filtered so a changed or deleted ConfigMap is republished
+	generation := builder.WithPredicates(predicate.Or(resource.IgnoreUpdateWithoutGenerationChangePredicate(), pause.Predicate()))
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o).
-		For(&orgv1alpha1.Deployment{}).
-		Owns(&orgv1alpha1.Deployment{}).
-		WithEventFilter(resource.IgnoreUpdateWithoutGenerationChangePredicate()).
-		Watches(&source.Kind{Type: &orgv1alpha1.Organization{}}, orgHandler).
-		Complete(r)
+		For(&orgv1alpha1.Deployment{}, generation).
+		Owns(&orgv1alpha1.Deployment{}, generation).
+		Watches(&source.Kind{Type: &orgv1alpha1.Organization{}}, orgHandler, generation).
+		Watches(&source.Kind{Type: &orgv1alpha1.RegisterPolicy{}}, policyHandler, generation).
+		Watches(&source.Kind{Type: &orgv1alpha1.DeploymentClass{}}, classHandler, generation).
+		Watches(&source.Kind{Type: &orgv1alpha1.DeploymentKindDefinition{}}, kindHandler, generation).
+		// the published ConfigMaps are watched through the cache of the
+		// registry ConfigMaps, the cache of the manager holds no ConfigMaps
+		Watches(source.NewKindWithCache(&corev1.ConfigMap{}, nddcopts.ConfigMaps),
+			&ctrlhandler.EnqueueRequestForOwner{OwnerType: &orgv1alpha1.Deployment{}, IsController: true}).
+		Watches(source.NewKindWithCache(&corev1.ConfigMap{}, nddcopts.ConfigMaps), configMapHandler).
+		Complete(nddcopts.Readiness.NewReconciler(name,
+			func() client.ObjectList { return &orgv1alpha1.DeploymentList{} },
+			tracing.NewReconciler(name, handler.NewReconciler(nddcopts.Handler,
+				pause.NewReconciler(mgr.GetClient(), nddcopts.Handler, func() pause.Object { return &orgv1alpha1.Deployment{} }, r),
+			)),
+		))
 
 }
 
 type application struct {
 	client resource.ClientApplicator
+	// reader reads the published ConfigMaps from the cache of the registry
+	// ConfigMaps
+	reader client.Reader
 	log    logging.Logger
 
 	newDep     func() orgv1alpha1.Dp
 	newOrgList func() orgv1alpha1.OrgList
+	newRpList  func() orgv1alpha1.RpList
+	newDc      func() orgv1alpha1.Dc
+	newDkd     func() orgv1alpha1.Dkd
+	newDepList func() orgv1alpha1.DpList
+
+	// ids allocates the deployment ids per organization
+	ids *idalloc.Allocator
 
 	handler handler.Handler
+	record  event.Recorder
+
+	// crossNamespace allows organizations of other namespaces
+	crossNamespace bool
 }
 
 func getCrName(cr orgv1alpha1.Dp) string {
@@ -121,37 +209,64 @@ func (r *application) Update(ctx context.Context, mg resource.Managed) (map[stri
 		return nil, errors.New(errUnexpectedResource)
 	}
 
-	return r.handleAppLogic(ctx, cr)
+	src := &organizationRecorder{application: r}
+	observed, err := r.handleAppLogic(ctx, src, cr)
+	// the ConfigMap is published with the status, also when it is down
+	perr := r.publish(ctx, src, cr)
+	switch {
+	case err != nil && perr != nil:
+		return observed, fmt.Errorf("%s: %w; %s: %v", errResolveDeployment, err, errPublishConfigMap, perr)
+	case err != nil:
+		return observed, fmt.Errorf("%s: %w", errResolveDeployment, err)
+	case perr != nil:
+		return observed, fmt.Errorf("%s: %w", errPublishConfigMap, perr)
+	}
+	return observed, nil
+}
+
+// publish publishes the ConfigMap of the deployment. The organization is only
+// fetched when the resolution stopped before it fetched the organization.
+func (r *application) publish(ctx context.Context, src *organizationRecorder, cr orgv1alpha1.Dp) error {
+	if !src.fetched {
+		if _, err := src.GetOrganization(ctx, cr); err != nil {
+			return err
+		}
+	}
+	return r.publishConfigMap(ctx, src.org, cr)
+}
+
+// organizationRecorder records the organization the resolution of a
+// deployment fetched.
+type organizationRecorder struct {
+	*application
+	org     orgv1alpha1.Org
+	fetched bool
+}
+
+func (o *organizationRecorder) GetOrganization(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Org, error) {
+	org, err := o.application.GetOrganization(ctx, cr)
+	o.org, o.fetched = org, err == nil
+	return org, err
 }
 
 func (r *application) FinalUpdate(ctx context.Context, mg resource.Managed) {
 }
 
 func (r *application) Timeout(ctx context.Context, mg resource.Managed) time.Duration {
-	/*
-		cr, _ := mg.(*orgv1alpha1.Deployment)
-		crName := getCrName(cr)
-		speedy := r.handler.GetSpeedy(crName)
-		if speedy <= 2 {
-			r.handler.IncrementSpeedy(crName)
-			r.log.Debug("Speedy incr", "number", r.handler.GetSpeedy(crName))
-			switch speedy {
-			case 0:
-				return veryShortWait
-			case 1, 2:
-				return shortWait
-			}
-
-		}
-	*/
+	// the requeue is decided by the requeue policy of the handler
 	return reconcileTimeout
 }
 
 func (r *application) Delete(ctx context.Context, mg resource.Managed) (bool, error) {
-	_, ok := mg.(*orgv1alpha1.Deployment)
+	cr, ok := mg.(*orgv1alpha1.Deployment)
 	if !ok {
 		return true, errors.New(errUnexpectedResource)
 	}
+	if ref := cr.GetStateConfigMap(); ref != nil {
+		if err := r.deleteConfigMap(ctx, cr, *ref); err != nil {
+			return true, err
+		}
+	}
 	//if err := r.handler.DeleteDeploymentNamespace(ctx, cr); err != nil {
 	//	return true, err
 	//}
@@ -167,69 +282,134 @@ func (r *application) FinalDelete(ctx context.Context, mg resource.Managed) {
 	r.handler.Delete(crName)
 }
 
-func (r *application) handleAppLogic(ctx context.Context, cr orgv1alpha1.Dp) (map[string]string, error) {
+func (r *application) handleAppLogic(ctx context.Context, src registry.DeploymentSource, cr orgv1alpha1.Dp) (map[string]string, error) {
 	log := r.log.WithValues("function", "handleAppLogic", "crname", cr.GetName())
 	log.Debug("handleAppLogic")
 
-	// initialize speedy
 	crName := getCrName(cr)
-	r.handler.Init(crName)
+	r.handler.Observe(crName, cr.GetGeneration())
 
-	orgs := r.newOrgList()
-	if err := r.client.List(ctx, orgs); err != nil {
+	defer r.recordEvents(cr, observe(cr))
+
+	//if err := r.handler.CreateDeploymentNamespace(ctx, cr); err != nil {
+	//	return make(map[string]string), err
+	//}
+
+	missing, err := registry.ResolveDeployment(ctx, log, src, cr)
+	if missing {
+		r.handler.MissingDependency(crName)
+	}
+	if err != nil {
 		return nil, err
 	}
+	return make(map[string]string), nil
+}
+
+func (r *application) AllowCrossNamespaceOrganizations() bool {
+	return r.crossNamespace
+}
 
-	orgfound := false
-	orgRegister := make(map[string]string)
-	var orgAddressAllocationStrategy *nddov1.AddressAllocationStrategy
-	for _, org := range orgs.GetOrganizations() {
-		log.Debug("org matches", "orgname", org.GetName(), "depNamespace", cr.GetNamespace())
-		if org.GetOrganizationName() == cr.GetOrganizationName() {
-			orgfound = true
-			orgRegister = org.GetRegister()
-			orgAddressAllocationStrategy = org.GetAddressAllocationStrategy()
-			break
+// GetOrganization returns the organization of the deployment, nil when it
+// does not exist. An organization of another namespace is rejected unless
+// cross namespace organizations are allowed.
+func (r *application) GetOrganization(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Org, error) {
+	if err := registry.ValidateOrganizationNamespace(cr, r.crossNamespace); err != nil {
+		return nil, err
+	}
+	orgs := r.newOrgList()
+	if err := r.client.List(ctx, orgs, scopedListOptions(cr.GetNamespace(), r.crossNamespace)...); err != nil {
+		return nil, err
+	}
+	for _, o := range orgs.GetOrganizations() {
+		if isOrganizationOf(o, cr) {
+			return o, nil
 		}
 	}
-	if !orgfound {
-		cr.SetStatus("down")
-		cr.SetReason("organization not found")
-		cr.SetStateRegister(make(map[string]string))
-		return nil, errors.New("organization not found")
+	return nil, nil
+}
+
+// GetDeploymentClass returns the deployment class of the deployment, nil
+// when the deployment does not reference one.
+func (r *application) GetDeploymentClass(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dc, error) {
+	name := cr.GetDeploymentClassName()
+	if name == "" {
+		return nil, nil
 	}
+	class := r.newDc()
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: cr.GetNamespace(), Name: name}, class); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
 
-	//if err := r.handler.CreateDeploymentNamespace(ctx, cr); err != nil {
-	//	return make(map[string]string), err
-	//}
+// GetDeploymentKind returns the definition of the kind of the deployment,
+// nil for a built in kind without a definition. The definition of any other
+// kind has to exist.
+func (r *application) GetDeploymentKind(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dkd, error) {
+	name := cr.GetKind()
+	if name == "" {
+		return nil, nil
+	}
+	kind := r.newDkd()
+	if err := r.client.Get(ctx, types.NamespacedName{Name: name}, kind); err != nil {
+		if kerrors.IsNotFound(err) && orgv1alpha1.IsBuiltinDeploymentKind(name) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return kind, nil
+}
 
-	if cr.GetAdminState() == "disable" {
-		cr.SetStatus("down")
-		cr.SetReason("admin state disabled")
-		cr.SetStateRegister(make(map[string]string))
-	} else {
-		cr.SetStatus("up")
-		cr.SetReason("")
-		depRegister := getDeploymentRegister(orgRegister, cr.GetRegister())
-		cr.SetStateRegister(depRegister)
-		aas := getDeploymentAddresssAllocationStrategy(orgAddressAllocationStrategy, cr.GetAddressAllocationStrategy())
-		cr.SetStateAddressAllocationStrategy(aas)
+// ListRegisterPolicies returns the register policies in the namespace of the
+// organization.
+func (r *application) ListRegisterPolicies(ctx context.Context, org orgv1alpha1.Org) ([]orgv1alpha1.Rp, error) {
+	policies := r.newRpList()
+	if err := r.client.List(ctx, policies, client.InNamespace(org.GetNamespace())); err != nil {
+		return nil, err
 	}
-	return make(map[string]string), nil
+	return policies.GetRegisterPolicies(), nil
 }
 
-func getDeploymentRegister(orgRegister, depRegister map[string]string) map[string]string {
-	for orgKind, orgName := range orgRegister {
-		if _, ok := depRegister[orgKind]; !ok {
-			depRegister[orgKind] = orgName
+// AllocateDeploymentID returns the id of the deployment in its organization,
+// the recorded id is kept unless an older deployment of the organization
+// records the same id.
+func (r *application) AllocateDeploymentID(ctx context.Context, org orgv1alpha1.Org, cr orgv1alpha1.Dp) (int64, error) {
+	deps := r.newDepList()
+	if err := r.client.List(ctx, deps, scopedListOptions(cr.GetNamespace(), r.crossNamespace)...); err != nil {
+		return 0, err
+	}
+	var claims []idalloc.Claim
+	for _, dep := range deps.GetDeployments() {
+		if isOrganizationOf(org, dep) {
+			claims = append(claims, claimOf(dep))
 		}
 	}
-	return depRegister
+	// sharding keeps all deployments of an organization in one replica
+	return r.ids.Allocate(string(org.GetUID()), claimOf(cr), claims)
+}
+
+func claimOf(dep orgv1alpha1.Dp) idalloc.Claim {
+	return idalloc.Claim{
+		UID:     dep.GetUID(),
+		ID:      dep.GetStateDeploymentID(),
+		Created: dep.GetCreationTimestamp(),
+	}
+}
+
+// isOrganizationOf returns true when org is the organization of the
+// deployment, a referenced namespace has to match as well.
+func isOrganizationOf(org orgv1alpha1.Org, dep orgv1alpha1.Dp) bool {
+	if ns := dep.GetOrganizationNamespace(); ns != "" && org.GetNamespace() != ns {
+		return false
+	}
+	return org.GetOrganizationName() == dep.GetOrganizationName()
 }
 
-func getDeploymentAddresssAllocationStrategy(orgass, depaas *nddov1.AddressAllocationStrategy) *nddov1.AddressAllocationStrategy {
-	if depaas != nil {
-		return depaas
+// scopedListOptions restricts a list to the namespace of the deployment or
+// organization, unless cross namespace organizations are allowed.
+func scopedListOptions(namespace string, crossNamespace bool) []client.ListOption {
+	if crossNamespace {
+		return nil
 	}
-	return orgass
+	return []client.ListOption{client.InNamespace(namespace)}
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yndd/ndd-runtime/pkg/event"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	"github.com/yndd/ndd-runtime/pkg/resource"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/idalloc"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// failingLister fails every List.
+type failingLister struct {
+	client.Client
+	err error
+}
+
+func (c failingLister) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.err
+}
+
+func deploymentOf(namespace, orgNamespace string) *orgv1alpha1.Deployment {
+	dep := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "nokia.dc1"}}
+	if orgNamespace != "" {
+		dep.Spec.Properties.OrganizationRef = &orgv1alpha1.OrganizationReference{Name: "nokia", Namespace: orgNamespace}
+	}
+	return dep
+}
+
+func TestGetOrganizationRejectsOtherNamespace(t *testing.T) {
+	// the organization is rejected before the organizations are listed
+	r := &application{}
+	org, err := r.GetOrganization(context.Background(), deploymentOf("default", "infra"))
+	if err == nil || org != nil {
+		t.Errorf("GetOrganization(...): want error and no organization, got %v, %v", org, err)
+	}
+}
+
+func TestUpdateSurfacesBothErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	h, err := handler.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &application{
+		client:     resource.ClientApplicator{Client: failingLister{err: errBoom}},
+		log:        logging.NewNopLogger(),
+		newOrgList: func() orgv1alpha1.OrgList { return &orgv1alpha1.OrganizationList{} },
+		handler:    h,
+		record:     event.NewNopRecorder(),
+	}
+	cr := deploymentOf("default", "")
+	if err := cr.InitializeResource(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Update(context.Background(), cr)
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Update(...): got %v, want %v", err, errBoom)
+	}
+	if !strings.Contains(err.Error(), errResolveDeployment) || !strings.Contains(err.Error(), errPublishConfigMap) {
+		t.Errorf("Update(...): %q does not report the resolve and the publish error", err)
+	}
+}
+
+// orgListCounter counts the organization lists.
+type orgListCounter struct {
+	client.Client
+	lists int
+}
+
+func (c *orgListCounter) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*orgv1alpha1.OrganizationList); ok {
+		c.lists++
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestUpdateGetsOrganizationOnce(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cr := deploymentOf("default", "")
+	if err := cr.InitializeResource(); err != nil {
+		t.Fatal(err)
+	}
+	org := &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}}
+	if err := org.InitializeResource(); err != nil {
+		t.Fatal(err)
+	}
+	org.SetStateOrganizationID(1)
+	c := &orgListCounter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(org, cr).Build()}
+	h, err := handler.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &application{
+		client:     resource.ClientApplicator{Client: c},
+		log:        logging.NewNopLogger(),
+		newOrgList: func() orgv1alpha1.OrgList { return &orgv1alpha1.OrganizationList{} },
+		newRpList:  func() orgv1alpha1.RpList { return &orgv1alpha1.RegisterPolicyList{} },
+		newDepList: func() orgv1alpha1.DpList { return &orgv1alpha1.DeploymentList{} },
+		ids:        idalloc.New(registry.MaxDeploymentID),
+		handler:    h,
+		record:     event.NewNopRecorder(),
+	}
+
+	if _, err := r.Update(context.Background(), cr); err != nil {
+		t.Fatalf("Update(...): %v", err)
+	}
+	if c.lists != 1 {
+		t.Errorf("Update(...): listed the organizations %d times, want 1", c.lists)
+	}
+}
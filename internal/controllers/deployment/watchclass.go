@@ -0,0 +1,88 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// EnqueueRequestForAllDeploymentClasses enqueues the deployments that
+// reference a deployment class.
+type EnqueueRequestForAllDeploymentClasses struct {
+	client client.Client
+	log    logging.Logger
+	ctx    context.Context
+
+	handler handler.Handler
+
+	newDepList func() orgv1alpha1.DpList
+}
+
+// Create enqueues a request for all deployments of the class.
+func (e *EnqueueRequestForAllDeploymentClasses) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update enqueues a request for all deployments of the class.
+func (e *EnqueueRequestForAllDeploymentClasses) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete enqueues a request for all deployments of the class.
+func (e *EnqueueRequestForAllDeploymentClasses) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic enqueues a request for all deployments of the class.
+func (e *EnqueueRequestForAllDeploymentClasses) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForAllDeploymentClasses) add(obj runtime.Object, queue adder) {
+	dc, ok := obj.(*orgv1alpha1.DeploymentClass)
+	if !ok {
+		return
+	}
+	log := e.log.WithValues("function", "watch deployment class", "name", dc.GetName(), "namespace", dc.GetNamespace())
+	log.Debug("handleEvent")
+
+	// deployments reference classes of their own namespace
+	d := e.newDepList()
+	if err := e.client.List(e.ctx, d, client.InNamespace(dc.GetNamespace())); err != nil {
+		return
+	}
+
+	for _, dep := range d.GetDeployments() {
+		if dep.GetDeploymentClassName() != dc.GetName() {
+			continue
+		}
+		e.handler.Reset(getCrName(dep))
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: dep.GetNamespace(),
+			Name:      dep.GetName()}})
+	}
+}
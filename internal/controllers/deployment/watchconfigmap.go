@@ -0,0 +1,77 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// EnqueueRequestForPublishedConfigMaps enqueues the deployment a ConfigMap
+// in the publication namespace of its organization is published for. The
+// ConfigMaps in the namespace of the deployment are owned by it and watched
+// through their owner reference.
+type EnqueueRequestForPublishedConfigMaps struct {
+	log logging.Logger
+
+	handler handler.Handler
+}
+
+// Create enqueues a request for the deployment the ConfigMap is published for.
+func (e *EnqueueRequestForPublishedConfigMaps) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update enqueues a request for the deployment the ConfigMap is published for.
+func (e *EnqueueRequestForPublishedConfigMaps) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete enqueues a request for the deployment the ConfigMap is published for.
+func (e *EnqueueRequestForPublishedConfigMaps) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic enqueues a request for the deployment the ConfigMap is published for.
+func (e *EnqueueRequestForPublishedConfigMaps) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForPublishedConfigMaps) add(obj runtime.Object, queue adder) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+	l := cm.GetLabels()
+	namespace, name := l[labelDeploymentNamespace], l[labelDeploymentName]
+	if l[labelManagedBy] != managedBy || namespace == "" || name == "" || namespace == cm.GetNamespace() {
+		return
+	}
+	log := e.log.WithValues("function", "watch configmap", "name", cm.GetName(), "namespace", cm.GetNamespace())
+	log.Debug("handleEvent", "depl namespace", namespace, "depl name", name)
+
+	e.handler.Reset(namespace + "." + name)
+	queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{
+		Namespace: namespace,
+		Name:      name}})
+}
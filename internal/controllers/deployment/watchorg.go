@@ -43,6 +43,8 @@ type EnqueueRequestForAllOrganizations struct {
 	handler handler.Handler
 
 	newDepList func() orgv1alpha1.DpList
+
+	crossNamespace bool
 }
 
 // Create enqueues a request for all infrastructures which pertains to the topology.
@@ -75,17 +77,17 @@ func (e *EnqueueRequestForAllOrganizations) add(obj runtime.Object, queue adder)
 	log.Debug("handleEvent")
 
 	d := e.newDepList()
-	if err := e.client.List(e.ctx, d); err != nil {
+	if err := e.client.List(e.ctx, d, scopedListOptions(dd.GetNamespace(), e.crossNamespace)...); err != nil {
 		return
 	}
 
 	for _, dep := range d.GetDeployments() {
 		// only enqueue if the organization name match
 		log.Debug("handleEvent", "depl namespace", dep.GetNamespace(), "depl name", dep.GetName(), "depl org", dep.GetOrganizationName())
-		if dep.GetOrganizationName() == dd.GetOrganizationName() {
+		if isOrganizationOf(dd, dep) {
 
 			crName := getCrName(dep)
-			e.handler.ResetSpeedy(crName)
+			e.handler.Reset(crName)
 
 			queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{
 				Namespace: dep.GetNamespace(),
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployment
+
+import (
+	"context"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// EnqueueRequestForAllRegisterPolicies enqueues the deployments of the
+// organization of a register policy.
+type EnqueueRequestForAllRegisterPolicies struct {
+	client client.Client
+	log    logging.Logger
+	ctx    context.Context
+
+	handler handler.Handler
+
+	newDepList func() orgv1alpha1.DpList
+
+	crossNamespace bool
+}
+
+// Create enqueues a request for all deployments of the policy organization.
+func (e *EnqueueRequestForAllRegisterPolicies) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update enqueues a request for all deployments of the policy organization.
+func (e *EnqueueRequestForAllRegisterPolicies) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectOld, q)
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete enqueues a request for all deployments of the policy organization.
+func (e *EnqueueRequestForAllRegisterPolicies) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic enqueues a request for all deployments of the policy organization.
+func (e *EnqueueRequestForAllRegisterPolicies) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForAllRegisterPolicies) add(obj runtime.Object, queue adder) {
+	rp, ok := obj.(*orgv1alpha1.RegisterPolicy)
+	if !ok {
+		return
+	}
+	log := e.log.WithValues("function", "watch register policy", "name", rp.GetName(), "namespace", rp.GetNamespace())
+	log.Debug("handleEvent")
+
+	d := e.newDepList()
+	if err := e.client.List(e.ctx, d, scopedListOptions(rp.GetNamespace(), e.crossNamespace)...); err != nil {
+		return
+	}
+
+	for _, dep := range d.GetDeployments() {
+		if dep.GetOrganizationName() != rp.GetOrganizationName() {
+			continue
+		}
+		// the organization of the policy lives in the namespace of the policy
+		if ns := dep.GetOrganizationNamespace(); ns != "" && ns != rp.GetNamespace() {
+			continue
+		}
+		e.handler.Reset(getCrName(dep))
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: dep.GetNamespace(),
+			Name:      dep.GetName()}})
+	}
+}
@@ -28,15 +28,24 @@ import (
 	"github.com/yndd/ndd-runtime/pkg/resource"
 	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
 	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/idalloc"
+	"github.com/yndd/nddr-org-registry/internal/pause"
 	"github.com/yndd/nddr-org-registry/internal/shared"
+	"github.com/yndd/nddr-org-registry/internal/tracing"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 const (
 	// timers
 	reconcileTimeout = 1 * time.Minute
-	veryShortWait    = 1 * time.Second
+	// organizationIDTable is the ConfigMap of the organization ids in the
+	// namespace of the controller, the ids are unique in the cluster
+	organizationIDTable = "nddr-org-registry-organization-ids"
 	// errors
 	errUnexpectedResource = "unexpected organization object"
 	errGetK8sResource     = "cannot get organization resource"
@@ -47,19 +56,25 @@ func Setup(mgr ctrl.Manager, o controller.Options, nddcopts *shared.NddControlle
 	name := "nddo/" + strings.ToLower(orgv1alpha1.OrganizationGroupKind)
 	orgfn := func() orgv1alpha1.Org { return &orgv1alpha1.Organization{} }
 
-	r := managed.NewReconciler(mgr,
+	r := managed.NewReconciler(shared.UnchangedStatusSkipper(mgr),
 		resource.ManagedKind(orgv1alpha1.OrganizationGroupVersionKind),
 		managed.WithLogger(nddcopts.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(nddcopts.Poll),
 		managed.WithApplogic(&application{
 			client: resource.ClientApplicator{
 				Client:     mgr.GetClient(),
 				Applicator: resource.NewAPIPatchingApplicator(mgr.GetClient()),
 			},
+			ids: idalloc.NewTable(mgr.GetClient(), nddcopts.ConfigMaps,
+				types.NamespacedName{Namespace: nddcopts.Namespace, Name: organizationIDTable},
+				map[string]string{shared.LabelManagedBy: shared.ManagedBy},
+				registry.MaxOrganizationID),
 			log:     nddcopts.Logger.WithValues("applogic", name),
 			newOrg:  orgfn,
 			handler: nddcopts.Handler,
 		}),
-		managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		managed.WithRecorder(shared.RepeatedWarningFilter(
+			event.NewAPIRecorder(mgr.GetEventRecorderFor(name)), shared.WarningWindow)),
 	)
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -67,14 +82,19 @@ func Setup(mgr ctrl.Manager, o controller.Options, nddcopts *shared.NddControlle
 		WithOptions(o).
 		For(&orgv1alpha1.Organization{}).
 		Owns(&orgv1alpha1.Organization{}).
-		WithEventFilter(resource.IgnoreUpdateWithoutGenerationChangePredicate()).
-		WithEventFilter(resource.IgnoreUpdateWithoutGenerationChangePredicate()).
-		Complete(r)
+		WithEventFilter(predicate.Or(resource.IgnoreUpdateWithoutGenerationChangePredicate(), pause.Predicate())).
+		Complete(nddcopts.Readiness.NewReconciler(name,
+			func() client.ObjectList { return &orgv1alpha1.OrganizationList{} },
+			tracing.NewReconciler(name, handler.NewReconciler(nddcopts.Handler,
+				pause.NewReconciler(mgr.GetClient(), nddcopts.Handler, func() pause.Object { return &orgv1alpha1.Organization{} }, r),
+			)),
+		))
 
 }
 
 type application struct {
 	client resource.ClientApplicator
+	ids    *idalloc.Table
 	log    logging.Logger
 
 	newOrg func() orgv1alpha1.Org
@@ -113,38 +133,27 @@ func (r *application) FinalUpdate(ctx context.Context, mg resource.Managed) {
 }
 
 func (r *application) Timeout(ctx context.Context, mg resource.Managed) time.Duration {
-	/*
-		cr, _ := mg.(*orgv1alpha1.Organization)
-		crName := getCrName(cr)
-		speedy := r.handler.GetSpeedy(crName)
-		if speedy <= 2 {
-			r.handler.IncrementSpeedy(crName)
-			r.log.Debug("Speedy incr", "number", r.handler.GetSpeedy(crName))
-			switch speedy {
-			case 0:
-				return veryShortWait
-			case 1, 2:
-				return shortWait
-			}
-
-		}
-	*/
+	// the requeue is decided by the requeue policy of the handler
 	return reconcileTimeout
 }
 
 func (r *application) Delete(ctx context.Context, mg resource.Managed) (bool, error) {
-	_, ok := mg.(*orgv1alpha1.Organization)
+	cr, ok := mg.(*orgv1alpha1.Organization)
 	if !ok {
 		return true, errors.New(errUnexpectedResource)
 	}
 	//if err := r.handler.DeleteOrganizationNamespace(ctx, cr); err != nil {
 	//	return true, err
 	//}
+	// a failed release is retried, the id stays allocated until it succeeds
+	if err := r.ids.Release(ctx, cr.GetUID()); err != nil {
+		return true, err
+	}
 	return true, nil
 }
 
 func (r *application) FinalDelete(ctx context.Context, mg resource.Managed) {
-	cr, ok := mg.(*orgv1alpha1.Deployment)
+	cr, ok := mg.(*orgv1alpha1.Organization)
 	if !ok {
 		return
 	}
@@ -156,22 +165,24 @@ func (r *application) handleAppLogic(ctx context.Context, cr orgv1alpha1.Org) (m
 	log := r.log.WithValues("function", "handleAppLogic", "crname", cr.GetName())
 	log.Debug("handleAppLogic")
 
-	// initialize speedy
 	crName := getCrName(cr)
-	r.handler.Init(crName)
+	r.handler.Observe(crName, cr.GetGeneration())
 
 	//if err := r.handler.CreateOrganizationNamespace(ctx, cr); err != nil {
 	//	return make(map[string]string), err
 	//}
 
-	register := cr.GetRegister()
-	for key, registryName := range register {
+	id, err := r.ids.Allocate(ctx, cr.GetUID(), cr.GetStateOrganizationID())
+	if err != nil {
+		return nil, err
+	}
+
+	for key, registryName := range cr.GetRegister() {
 		log.Debug("register", "key", key, "registryName", registryName)
 	}
-	aas := cr.GetAddressAllocationStrategy()
-	cr.SetStatus("up")
-	cr.SetReason("")
-	cr.SetStateRegister(register)
-	cr.SetStateAddressAllocationStrategy(aas)
+	if err := registry.ResolveOrganization(cr, id); err != nil {
+		// consumers cannot resolve the register of the organization
+		r.handler.MissingDependency(crName)
+	}
 	return make(map[string]string), nil
 }
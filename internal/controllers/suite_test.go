@@ -0,0 +1,190 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	orgv1alpha2 "github.com/yndd/nddr-org-registry/apis/org/v1alpha2"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/readiness"
+	"github.com/yndd/nddr-org-registry/internal/shared"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+const (
+	// defaultAssets is where envtest looks for its binaries when
+	// KUBEBUILDER_ASSETS is not set
+	defaultAssets = "/usr/local/kubebuilder/bin"
+	// envRequired makes the suite fail instead of skip without the envtest
+	// binaries, it is set by `make test` and CI
+	envRequired = "ENVTEST_REQUIRED"
+
+	eventuallyTimeout  = 30 * time.Second
+	eventuallyInterval = 100 * time.Millisecond
+)
+
+// k8sClient talks to the api server of the suite.
+var k8sClient client.Client
+
+// k8sManager runs the controllers of the suite, clusters added by the tests
+// are started by it.
+var k8sManager ctrl.Manager
+
+// TestMain starts an api server with the generated CRDs of the package and
+// runs both controllers against it. The binaries of the api server and etcd
+// are installed by `make test`, without them the suite is skipped unless
+// ENVTEST_REQUIRED is set.
+func TestMain(m *testing.M) {
+	if !assetsInstalled() {
+		fmt.Fprintf(os.Stderr, "envtest binaries not found in KUBEBUILDER_ASSETS or %s, run `make test`\n", defaultAssets)
+		if os.Getenv(envRequired) != "" {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "skipping the controller suite, set %s to fail instead\n", envRequired)
+		os.Exit(0)
+	}
+
+	scheme, err := newScheme()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot build scheme: %v\n", err)
+		os.Exit(1)
+	}
+	testEnv := &envtest.Environment{
+		// the CRDs are generated by `make manifests`, the conversion
+		// webhook of the v1alpha2 storage version is served by the manager
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "package", "crds")},
+		ErrorIfCRDPathMissing: true,
+		CRDInstallOptions:     envtest.CRDInstallOptions{Scheme: scheme},
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot start envtest: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := startManager(ctx, cfg, scheme, testEnv.WebhookInstallOptions); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot start manager: %v\n", err)
+		cancel()
+		_ = testEnv.Stop()
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	cancel()
+	if err := testEnv.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot stop envtest: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+func assetsInstalled() bool {
+	dir := os.Getenv("KUBEBUILDER_ASSETS")
+	if dir == "" {
+		dir = defaultAssets
+	}
+	for _, bin := range []string{"kube-apiserver", "etcd"} {
+		if _, err := os.Stat(filepath.Join(dir, bin)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func newScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		orgv1alpha1.AddToScheme,
+		orgv1alpha2.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+// startManager runs the controllers and the conversion webhook the way the
+// start command does and sets k8sClient and k8sManager.
+func startManager(ctx context.Context, cfg *rest.Config, scheme *runtime.Scheme, webhook envtest.WebhookInstallOptions) error {
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: "0",
+		Host:               webhook.LocalServingHost,
+		Port:               webhook.LocalServingPort,
+		CertDir:            webhook.LocalServingCertDir,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&orgv1alpha2.Organization{}).Complete(); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&orgv1alpha2.Deployment{}).Complete(); err != nil {
+		return err
+	}
+
+	h, err := handler.New(
+		handler.WithLogger(logging.NewNopLogger()),
+		handler.WithClient(mgr.GetClient()),
+		handler.WithPollInterval(5*time.Second),
+	)
+	if err != nil {
+		return err
+	}
+
+	nddcopts := &shared.NddControllerOptions{
+		Logger:    logging.NewNopLogger(),
+		Poll:      5 * time.Second,
+		Namespace: metav1.NamespaceDefault,
+		Handler:   h,
+		Readiness: readiness.NewReconciles(mgr.GetClient()),
+	}
+	if err := Setup(mgr, controller.Options{MaxConcurrentReconciles: 1}, nddcopts); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "manager stopped: %v\n", err)
+		}
+	}()
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		return errors.New("cannot sync cache")
+	}
+	k8sClient = mgr.GetClient()
+	k8sManager = mgr
+	return nil
+}
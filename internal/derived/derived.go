@@ -0,0 +1,106 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package derived renders the identifiers an organization derives for each
+// of its deployments, e.g. the route target base or the hostname prefix.
+package derived
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+)
+
+const (
+	// errors
+	errParseTemplate    = "cannot parse derived template %q"
+	errRenderTemplate   = "cannot render derived template %q"
+	errDecodeAttributes = "cannot decode attributes"
+)
+
+// Data is the data the derived templates are rendered with.
+type Data struct {
+	Organization   string
+	Deployment     string
+	Namespace      string
+	Region         string
+	Kind           string
+	OrgASN         int64
+	OrganizationID int64
+	DeploymentID   int64
+	// Register is the effective register of the deployment by kind
+	Register map[string]string
+	Labels   map[string]string
+	// Attributes are the kind specific attributes of the deployment
+	Attributes map[string]interface{}
+}
+
+// NewData returns the data of the deployment with the supplied effective
+// register.
+func NewData(org orgv1alpha1.Org, dep orgv1alpha1.Dp, register map[string]string) (Data, error) {
+	d := Data{
+		Organization:   dep.GetOrganizationName(),
+		Deployment:     dep.GetDeploymentName(),
+		Namespace:      dep.GetNamespace(),
+		Region:         dep.GetRegion(),
+		Kind:           dep.GetKind(),
+		OrgASN:         org.GetASN(),
+		OrganizationID: dep.GetStateOrganizationID(),
+		DeploymentID:   dep.GetStateDeploymentID(),
+		Register:       register,
+		Labels:         dep.GetDeploymentLabels(),
+		Attributes:     make(map[string]interface{}),
+	}
+	if a := dep.GetAttributes(); a != nil && len(a.Raw) > 0 {
+		if err := json.Unmarshal(a.Raw, &d.Attributes); err != nil {
+			return Data{}, errors.Wrap(err, errDecodeAttributes)
+		}
+	}
+	return d, nil
+}
+
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// Render returns the rendered templates by name. A missing map key, e.g. a
+// register kind the deployment does not have, is an error.
+func Render(templates map[string]string, data Data) (map[string]string, error) {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	derived := make(map[string]string, len(templates))
+	for _, name := range names {
+		t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(templates[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, errParseTemplate, name)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, errors.Wrapf(err, errRenderTemplate, name)
+		}
+		derived[name] = b.String()
+	}
+	return derived, nil
+}
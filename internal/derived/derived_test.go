@@ -0,0 +1,94 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package derived
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRender(t *testing.T) {
+	org := &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}}
+	org.Spec.Properties.ASN = func(i int64) *int64 { return &i }(65000)
+
+	dep := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"}}
+	dep.Spec.Properties.Region = utils.StringPtr("eu-west")
+	dep.Spec.Properties.Attributes = &apiextensionsv1.JSON{Raw: []byte(`{"fabric-size": 8}`)}
+	_ = dep.InitializeResource()
+	dep.SetStateOrganizationID(3)
+	dep.SetStateDeploymentID(12)
+
+	data, err := NewData(org, dep, map[string]string{"ipam": "nokia-ipam"})
+	if err != nil {
+		t.Fatalf("NewData(): %v", err)
+	}
+
+	cases := map[string]struct {
+		templates map[string]string
+		want      map[string]string
+		wantErr   bool
+	}{
+		"Identifiers": {
+			templates: map[string]string{
+				"rt-base":         "{{.OrgASN}}:{{.DeploymentID}}00",
+				"rd-base":         `{{.OrganizationID}}:{{printf "%04d" .DeploymentID}}`,
+				"hostname-prefix": "{{upper .Deployment}}-{{.Region}}",
+				"vrf-prefix":      "{{.Organization}}-{{index .Register \"ipam\"}}",
+				"fabric":          `{{index .Attributes "fabric-size"}}`,
+			},
+			want: map[string]string{
+				"rt-base":         "65000:1200",
+				"rd-base":         "3:0012",
+				"hostname-prefix": "DC1-eu-west",
+				"vrf-prefix":      "nokia-nokia-ipam",
+				"fabric":          "8",
+			},
+		},
+		"None": {
+			want: map[string]string{},
+		},
+		"MissingRegister": {
+			templates: map[string]string{"vrf-prefix": "{{.Register.vlan}}"},
+			wantErr:   true,
+		},
+		"UnknownField": {
+			templates: map[string]string{"rt-base": "{{.ASN}}"},
+			wantErr:   true,
+		},
+		"Malformed": {
+			templates: map[string]string{"rt-base": "{{.OrgASN"},
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Render(tc.templates, data)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Render(): want error %t, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Render(): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dryrun provides a client that reads from the cluster but records
+// the writes it would have made instead of applying them.
+package dryrun
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+const (
+	fieldStatus = "status"
+
+	// errors
+	errGetLive   = "cannot get live object"
+	errConvert   = "cannot convert object"
+	errObjectGVK = "cannot get group version kind of object"
+)
+
+// NewClientFunc returns a cluster.NewClientFunc that wraps the default client
+// of the manager into a dry-run client recording to the supplied report.
+func NewClientFunc(r *Report) cluster.NewClientFunc {
+	return func(c cache.Cache, config *rest.Config, o client.Options, uncached ...client.Object) (client.Client, error) {
+		cl, err := cluster.DefaultNewClient(c, config, o, uncached...)
+		if err != nil {
+			return nil, err
+		}
+		return NewClient(cl, r), nil
+	}
+}
+
+// NewClient returns a client that passes reads to c and records the diff of
+// every write in r instead of applying it.
+func NewClient(c client.Client, r *Report) client.Client {
+	return &dryRunClient{Client: c, report: r}
+}
+
+type dryRunClient struct {
+	client.Client
+	report *Report
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.record(ctx, obj, "", true)
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.record(ctx, obj, "", false)
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.record(ctx, obj, "", false)
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return errors.Wrap(err, errObjectGVK)
+	}
+	c.report.Record(gvk.Kind, obj.GetNamespace(), obj.GetName(), []string{"deleted"})
+	return nil
+}
+
+func (c *dryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return nil
+}
+
+func (c *dryRunClient) Status() client.StatusWriter {
+	return &dryRunStatusWriter{client: c}
+}
+
+type dryRunStatusWriter struct {
+	client *dryRunClient
+}
+
+func (w *dryRunStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return w.client.record(ctx, obj, fieldStatus, false)
+}
+
+func (w *dryRunStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.client.record(ctx, obj, fieldStatus, false)
+}
+
+// record diffs obj against the live object and records the changes. Only
+// the status is diffed for a status write, everything but the status
+// otherwise.
+func (c *dryRunClient) record(ctx context.Context, obj client.Object, subresource string, create bool) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return errors.Wrap(err, errObjectGVK)
+	}
+
+	var live map[string]interface{}
+	if !create {
+		current, ok := obj.DeepCopyObject().(client.Object)
+		if !ok {
+			return errors.New(errConvert)
+		}
+		err := c.Get(ctx, client.ObjectKeyFromObject(obj), current)
+		switch {
+		case kerrors.IsNotFound(err):
+			// the write would fail, nothing to record
+			return err
+		case err != nil:
+			return errors.Wrap(err, errGetLive)
+		}
+		if live, err = runtime.DefaultUnstructuredConverter.ToUnstructured(current); err != nil {
+			return errors.Wrap(err, errConvert)
+		}
+	}
+	desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return errors.Wrap(err, errConvert)
+	}
+
+	if subresource != "" {
+		live = map[string]interface{}{subresource: live[subresource]}
+		desired = map[string]interface{}{subresource: desired[subresource]}
+	} else {
+		delete(live, fieldStatus)
+		delete(desired, fieldStatus)
+	}
+
+	changes := Diff(live, desired)
+	if create {
+		changes = append([]string{"created"}, changes...)
+	}
+	if len(changes) > 0 {
+		c.report.Record(gvk.Kind, obj.GetNamespace(), obj.GetName(), changes)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const noValue = "<none>"
+
+// ignoredFields change on every write or every reconcile and would hide the
+// actual changes.
+var ignoredFields = map[string]bool{
+	"resourceVersion":    true,
+	"managedFields":      true,
+	"generation":         true,
+	"creationTimestamp":  true,
+	"uid":                true,
+	"lastTransitionTime": true,
+}
+
+// Diff returns the changes from old to new as "path: old -> new", sorted by
+// path. Maps are compared per field, any other value as a whole.
+func Diff(old, new map[string]interface{}) []string {
+	var changes []string
+	diff("", prune(old), prune(new), &changes)
+	sort.Strings(changes)
+	return changes
+}
+
+func diff(path string, old, new interface{}, changes *[]string) {
+	o, oldIsMap := old.(map[string]interface{})
+	n, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]struct{}, len(o)+len(n))
+		for k := range o {
+			keys[k] = struct{}{}
+		}
+		for k := range n {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diff(p, o[k], n[k], changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", path, render(old), render(new)))
+}
+
+// prune drops the ignored and empty fields.
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if ignoredFields[k] {
+				continue
+			}
+			if e = prune(e); e != nil {
+				out[k] = e
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		out := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			out = append(out, prune(e))
+		}
+		return out
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	return v
+}
+
+func render(v interface{}) string {
+	if v == nil {
+		return noValue
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+)
+
+// Entry holds the changes the last write to an object would have applied.
+type Entry struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Changes   []string  `json:"changes"`
+	Time      time.Time `json:"time"`
+}
+
+// A Report collects the writes a dry-run client would have applied, one
+// entry per object. It serves the entries as json.
+type Report struct {
+	log logging.Logger
+
+	m       sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewReport returns an empty report that logs every new diff to log.
+func NewReport(log logging.Logger) *Report {
+	return &Report{
+		log:     log,
+		entries: make(map[string]Entry),
+	}
+}
+
+// Record replaces the changes of the object. A diff is only logged when it
+// differs from the one recorded before, the same diff is computed on every
+// reconcile since nothing is written.
+func (r *Report) Record(kind, namespace, name string, changes []string) {
+	key := kind + "/" + namespace + "/" + name
+
+	r.m.Lock()
+	prev, ok := r.entries[key]
+	r.entries[key] = Entry{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Changes:   changes,
+		Time:      time.Now(),
+	}
+	r.m.Unlock()
+
+	if !ok || !reflect.DeepEqual(prev.Changes, changes) {
+		r.log.Info("dry-run diff", "kind", kind, "namespace", namespace, "name", name, "changes", changes)
+	}
+}
+
+// Entries returns the entries sorted by kind, namespace and name.
+func (r *Report) Entries() []Entry {
+	r.m.RLock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	r.m.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		if entries[i].Namespace != entries[j].Namespace {
+			return entries[i].Namespace < entries[j].Namespace
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func (r *Report) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Entries()); err != nil {
+		r.log.Debug("cannot write dry-run report", "error", err)
+	}
+}
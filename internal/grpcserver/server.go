@@ -0,0 +1,287 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	"github.com/yndd/nddr-org-registry/pkg/registryclient"
+	"github.com/yndd/nddr-org-registry/pkg/registrypb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// errors
+	errNoCredentials = "the grpc server requires credentials"
+	errListen        = "cannot listen on grpc server address"
+	errLoadTLS       = "cannot load grpc server certificate"
+	errUnauthorized  = "invalid username or password"
+)
+
+func New(address string, opts ...Option) Server {
+	s := &server{
+		address: address,
+		log:     logging.NewNopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type server struct {
+	registrypb.UnimplementedRegistryServer
+
+	address string
+	log     logging.Logger
+	// kubernetes
+	client   client.Client
+	registry registry.Registry
+
+	username string
+	password string
+	certFile string
+	keyFile  string
+}
+
+func (s *server) WithLogger(log logging.Logger) {
+	s.log = log
+}
+
+func (s *server) WithClient(c client.Client) {
+	s.client = c
+}
+
+func (s *server) WithRegistry(r registry.Registry) {
+	s.registry = r
+}
+
+func (s *server) WithCredentials(username, password string) {
+	s.username = username
+	s.password = password
+}
+
+func (s *server) WithTLS(certFile, keyFile string) {
+	s.certFile = certFile
+	s.keyFile = keyFile
+}
+
+// NeedLeaderElection returns false, every replica serves the api from its
+// own cache.
+func (s *server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *server) Start(ctx context.Context) error {
+	gs, err := s.newServer()
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return errors.Wrap(err, errListen)
+	}
+
+	go func() {
+		<-ctx.Done()
+		gs.GracefulStop()
+	}()
+
+	s.log.Debug("grpc server started", "address", s.address)
+	return gs.Serve(l)
+}
+
+// newServer returns the grpc server of the registry api.
+func (s *server) newServer() (*grpc.Server, error) {
+	if s.username == "" || s.password == "" {
+		return nil, errors.New(errNoCredentials)
+	}
+	opts := []grpc.ServerOption{
+		// the trace context of the caller is extracted before authentication
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), s.unaryAuth),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), s.streamAuth),
+	}
+	if s.certFile != "" && s.keyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.certFile, s.keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, errLoadTLS)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	gs := grpc.NewServer(opts...)
+	registrypb.RegisterRegistryServer(gs, s)
+	return gs, nil
+}
+
+func (s *server) GetRegister(ctx context.Context, req *registrypb.RegisterRequest) (*registrypb.RegisterReply, error) {
+	register, err := s.registry.GetRegisterByName(ctx, req.GetNamespace(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &registrypb.RegisterReply{Register: register}, nil
+}
+
+func (s *server) GetAddressAllocationStrategy(ctx context.Context, req *registrypb.RegisterRequest) (*registrypb.AddressAllocationStrategyReply, error) {
+	aas, err := s.registry.GetAddressAllocationStrategyByName(ctx, req.GetNamespace(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toAddressAllocationStrategyReply(aas), nil
+}
+
+func (s *server) ListDeployments(ctx context.Context, req *registrypb.ListDeploymentsRequest) (*registrypb.ListDeploymentsReply, error) {
+	deps := &orgv1alpha1.DeploymentList{}
+	opts := []client.ListOption{}
+	if req.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(req.GetNamespace()))
+	}
+	if err := s.client.List(ctx, deps, opts...); err != nil {
+		return nil, toStatus(err)
+	}
+
+	reply := &registrypb.ListDeploymentsReply{
+		Deployment: make([]*registrypb.Deployment, 0, len(deps.Items)),
+	}
+	for _, dep := range deps.GetDeployments() {
+		if req.GetOrganization() != "" && dep.GetOrganizationName() != req.GetOrganization() {
+			continue
+		}
+		reply.Deployment = append(reply.Deployment, &registrypb.Deployment{
+			Namespace:    dep.GetNamespace(),
+			Name:         dep.GetName(),
+			Organization: dep.GetOrganizationName(),
+			Deployment:   dep.GetDeploymentName(),
+			Kind:         dep.GetKind(),
+			Region:       dep.GetRegion(),
+			AdminState:   dep.GetAdminState(),
+			Status:       dep.GetStatus(),
+			Reason:       dep.GetReason(),
+			Register:     dep.GetStateRegister(),
+		})
+	}
+	return reply, nil
+}
+
+func (s *server) GetRegistryEndpoint(ctx context.Context, req *registrypb.RegistryEndpointRequest) (*registrypb.RegistryEndpointReply, error) {
+	address, err := s.registry.GetRegistryEndpoint(ctx, req.GetRegisterName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &registrypb.RegistryEndpointReply{Address: address}, nil
+}
+
+func (s *server) WatchRegister(req *registrypb.WatchRegisterRequest, stream registrypb.Registry_WatchRegisterServer) error {
+	name := types.NamespacedName{Namespace: req.GetNamespace(), Name: req.GetName()}
+	ch, err := s.registry.Watch(stream.Context(), name, registry.WithResumeToken(req.GetResumeToken()))
+	if err != nil {
+		return toStatus(err)
+	}
+	for ev := range ch {
+		reply := &registrypb.WatchRegisterReply{
+			Namespace:                 ev.Name.Namespace,
+			Name:                      ev.Name.Name,
+			Register:                  ev.Register,
+			AddressAllocationStrategy: toAddressAllocationStrategyReply(ev.AddressAllocationStrategy),
+			ResumeToken:               ev.ResumeToken,
+		}
+		if ev.Err != nil {
+			reply.Error = ev.Err.Error()
+		}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *server) streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authenticate(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (s *server) authenticate(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, errUnauthorized)
+	}
+	if !equal(md.Get(registryclient.MetadataUsername), s.username) ||
+		!equal(md.Get(registryclient.MetadataPassword), s.password) {
+		return status.Error(codes.Unauthenticated, errUnauthorized)
+	}
+	return nil
+}
+
+func equal(values []string, expected string) bool {
+	if len(values) != 1 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(values[0]), []byte(expected)) == 1
+}
+
+func toAddressAllocationStrategyReply(aas *nddov1.AddressAllocationStrategy) *registrypb.AddressAllocationStrategyReply {
+	reply := &registrypb.AddressAllocationStrategyReply{}
+	if aas == nil {
+		return reply
+	}
+	if aas.GatewayAllocation != nil {
+		reply.GatewayAllocation = aas.GatewayAllocation.String()
+	}
+	if aas.InfraItfcePrefixLengthIpv4 != nil {
+		reply.InfraInterfacePrefixLengthIpv4 = *aas.InfraItfcePrefixLengthIpv4
+	}
+	if aas.InfraItfcePrefixLengthIpv6 != nil {
+		reply.InfraInterfacePrefixLengthIpv6 = *aas.InfraItfcePrefixLengthIpv6
+	}
+	return reply
+}
+
+func toStatus(err error) error {
+	switch {
+	case kerrors.IsNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	case registry.IsCriticalRegisterError(err):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yndd/nddo-grpc/ndd"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/pkg/registry/registrytest"
+	"github.com/yndd/nddr-org-registry/pkg/registryclient"
+	"github.com/yndd/nddr-org-registry/pkg/registrypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// dial serves s on a bufconn listener and returns a client that
+// authenticates with the supplied credentials.
+func dial(t *testing.T, s *server, username, password string) registrypb.RegistryClient {
+	t.Helper()
+	gs, err := s.newServer()
+	if err != nil {
+		t.Fatalf("newServer(): %v", err)
+	}
+	lis := bufconn.Listen(1024 * 1024)
+	go gs.Serve(lis) // nolint:errcheck
+	t.Cleanup(gs.Stop)
+
+	conn, err := registryclient.Dial(context.Background(), &ndd.Config{
+		Address:  "bufnet",
+		Username: username,
+		Password: password,
+		Insecure: true,
+	}, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("Dial(...): %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return registrypb.NewRegistryClient(conn)
+}
+
+func TestNewServerRequiresCredentials(t *testing.T) {
+	s := New("", WithRegistry(registrytest.New())).(*server)
+	if _, err := s.newServer(); err == nil {
+		t.Errorf("newServer(): expected an error without credentials")
+	}
+}
+
+func TestGetRegister(t *testing.T) {
+	full := map[string]string{"ipam": "nokia-ipam", "as": "nokia-as", "ni": "nokia-ni"}
+	reg := registrytest.New(
+		registrytest.WithRegister("nokia", full),
+		registrytest.WithRegister("partial", map[string]string{"ipam": "partial-ipam"}),
+	)
+
+	cases := map[string]struct {
+		username string
+		password string
+		name     string
+		want     map[string]string
+		code     codes.Code
+	}{
+		"Register": {
+			username: "admin",
+			password: "secret",
+			name:     "nokia",
+			want:     full,
+			code:     codes.OK,
+		},
+		"WrongPassword": {
+			username: "admin",
+			password: "wrong",
+			name:     "nokia",
+			code:     codes.Unauthenticated,
+		},
+		"NoCredentials": {
+			name: "nokia",
+			code: codes.Unauthenticated,
+		},
+		"MissingCriticalRegister": {
+			username: "admin",
+			password: "secret",
+			name:     "partial",
+			code:     codes.FailedPrecondition,
+		},
+		"NoRegister": {
+			username: "admin",
+			password: "secret",
+			name:     "other",
+			code:     codes.Internal,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := New("", WithRegistry(reg), WithCredentials("admin", "secret")).(*server)
+			c := dial(t, s, tc.username, tc.password)
+
+			reply, err := c.GetRegister(context.Background(), &registrypb.RegisterRequest{Namespace: "default", Name: tc.name})
+			if got := status.Code(err); got != tc.code {
+				t.Fatalf("GetRegister(...): code %s, want %s: %v", got, tc.code, err)
+			}
+			if diff := cmp.Diff(tc.want, reply.GetRegister()); diff != "" {
+				t.Errorf("GetRegister(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListDeployments(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.region1"}},
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.region2"}},
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme.region1"}},
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "nokia.region3"}},
+	).Build()
+
+	cases := map[string]struct {
+		req  *registrypb.ListDeploymentsRequest
+		want []string
+	}{
+		"All": {
+			req:  &registrypb.ListDeploymentsRequest{},
+			want: []string{"default/acme.region1", "default/nokia.region1", "default/nokia.region2", "other/nokia.region3"},
+		},
+		"Namespace": {
+			req:  &registrypb.ListDeploymentsRequest{Namespace: "default"},
+			want: []string{"default/acme.region1", "default/nokia.region1", "default/nokia.region2"},
+		},
+		"Organization": {
+			req:  &registrypb.ListDeploymentsRequest{Namespace: "default", Organization: "nokia"},
+			want: []string{"default/nokia.region1", "default/nokia.region2"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := New("", WithClient(c), WithRegistry(registrytest.New()), WithCredentials("admin", "secret")).(*server)
+			rc := dial(t, s, "admin", "secret")
+
+			reply, err := rc.ListDeployments(context.Background(), tc.req)
+			if err != nil {
+				t.Fatalf("ListDeployments(...): %v", err)
+			}
+			got := make([]string, 0, len(reply.GetDeployment()))
+			for _, dep := range reply.GetDeployment() {
+				got = append(got, dep.GetNamespace()+"/"+dep.GetName())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ListDeployments(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
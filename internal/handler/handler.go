@@ -23,7 +23,8 @@ const (
 func New(opts ...Option) (Handler, error) {
 	//rgfn := func() niregv1alpha1.Rg { return &niregv1alpha1.Registry{} }
 	s := &handler{
-		speedy: make(map[string]int),
+		policy:  defaultRequeuePolicy(),
+		requeue: make(map[string]*requeueState),
 		//newRegistry: rgfn,
 	}
 
@@ -50,47 +51,9 @@ type handler struct {
 	// kubernetes
 	client resource.ClientApplicator
 
-	speedyMutex sync.Mutex
-	speedy      map[string]int
-}
-
-func (r *handler) Init(crName string) {
-	r.speedyMutex.Lock()
-	defer r.speedyMutex.Unlock()
-	if _, ok := r.speedy[crName]; !ok {
-		r.speedy[crName] = 0
-	}
-}
-
-func (r *handler) Delete(crName string) {
-	r.speedyMutex.Lock()
-	defer r.speedyMutex.Unlock()
-	delete(r.speedy, crName)
-}
-
-func (r *handler) ResetSpeedy(crName string) {
-	r.speedyMutex.Lock()
-	defer r.speedyMutex.Unlock()
-	if _, ok := r.speedy[crName]; ok {
-		r.speedy[crName] = 0
-	}
-}
-
-func (r *handler) GetSpeedy(crName string) int {
-	r.speedyMutex.Lock()
-	defer r.speedyMutex.Unlock()
-	if _, ok := r.speedy[crName]; ok {
-		return r.speedy[crName]
-	}
-	return 9999
-}
-
-func (r *handler) IncrementSpeedy(crName string) {
-	r.speedyMutex.Lock()
-	defer r.speedyMutex.Unlock()
-	if _, ok := r.speedy[crName]; ok {
-		r.speedy[crName]++
-	}
+	policy       requeuePolicy
+	requeueMutex sync.Mutex
+	requeue      map[string]*requeueState
 }
 
 func (r *handler) CreateOrganizationNamespace(ctx context.Context, cr orgv1alpha1.Org) error {
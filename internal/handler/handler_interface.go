@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"time"
 
 	"github.com/yndd/ndd-runtime/pkg/logging"
 	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
@@ -24,14 +25,29 @@ func WithClient(c client.Client) Option {
 	}
 }
 
+// WithPollInterval specifies how often a reconciled resource is checked for
+// drift once the fast requeue schedule is exhausted.
+func WithPollInterval(d time.Duration) Option {
+	return func(s Handler) {
+		s.WithPollInterval(d)
+	}
+}
+
 type Handler interface {
 	WithLogger(log logging.Logger)
 	WithClient(client.Client)
-	Init(string)
-	Delete(string)
-	ResetSpeedy(string)
-	GetSpeedy(crName string) int
-	IncrementSpeedy(crName string)
+	WithPollInterval(time.Duration)
+	// Observe starts a reconcile of the resource, a new generation restarts
+	// the fast requeue schedule.
+	Observe(crName string, generation int64)
+	// Reset restarts the fast requeue schedule, e.g. when a dependency changed.
+	Reset(crName string)
+	// MissingDependency backs off the requeue of the current reconcile.
+	MissingDependency(crName string)
+	// Requeue returns when the resource should be reconciled again.
+	Requeue(crName string) time.Duration
+	// Delete prunes the requeue state of the resource.
+	Delete(crName string)
 	CreateOrganizationNamespace(ctx context.Context, cr orgv1alpha1.Org) error
 	DeleteOrganizationNamespace(ctx context.Context, cr orgv1alpha1.Org) error
 	CreateDeploymentNamespace(ctx context.Context, cr orgv1alpha1.Dp) error
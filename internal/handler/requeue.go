@@ -0,0 +1,155 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	defaultPollInterval = 1 * time.Minute
+	backoffBase         = 1 * time.Second
+)
+
+// defaultFastSchedule is used after a spec or dependency change, it verifies
+// the change settled before falling back to the poll interval.
+var defaultFastSchedule = []time.Duration{1 * time.Second, 5 * time.Second, 15 * time.Second}
+
+// requeuePolicy decides when a resource is reconciled again: first along the
+// fast schedule, then every poll interval. While a dependency is missing the
+// requeue backs off exponentially from backoffBase up to the poll interval.
+type requeuePolicy struct {
+	fast        []time.Duration
+	poll        time.Duration
+	backoffBase time.Duration
+}
+
+func defaultRequeuePolicy() requeuePolicy {
+	return requeuePolicy{
+		fast:        defaultFastSchedule,
+		poll:        defaultPollInterval,
+		backoffBase: backoffBase,
+	}
+}
+
+// requeueState is the requeue state of a single resource.
+type requeueState struct {
+	generation int64
+	// step in the fast schedule
+	step int
+	// failures counts the consecutive reconciles with a missing dependency
+	failures int
+	// missing is set when the current reconcile misses a dependency
+	missing bool
+}
+
+// next returns the requeue delay of the resource and advances its state.
+func (p requeuePolicy) next(s *requeueState) time.Duration {
+	if s.missing {
+		s.missing = false
+		d := p.backoffBase
+		for i := 1; i < s.failures && d < p.poll; i++ {
+			d *= 2
+		}
+		if d > p.poll {
+			d = p.poll
+		}
+		return d
+	}
+	s.failures = 0
+	if s.step < len(p.fast) {
+		d := p.fast[s.step]
+		s.step++
+		return d
+	}
+	return p.poll
+}
+
+func (r *handler) WithPollInterval(d time.Duration) {
+	if d > 0 {
+		r.policy.poll = d
+	}
+}
+
+func (r *handler) Observe(crName string, generation int64) {
+	r.requeueMutex.Lock()
+	defer r.requeueMutex.Unlock()
+	s, ok := r.requeue[crName]
+	if !ok {
+		s = &requeueState{}
+		r.requeue[crName] = s
+	}
+	if s.generation != generation {
+		*s = requeueState{generation: generation}
+	}
+	s.missing = false
+}
+
+func (r *handler) Reset(crName string) {
+	r.requeueMutex.Lock()
+	defer r.requeueMutex.Unlock()
+	if s, ok := r.requeue[crName]; ok {
+		*s = requeueState{generation: s.generation}
+	}
+}
+
+func (r *handler) MissingDependency(crName string) {
+	r.requeueMutex.Lock()
+	defer r.requeueMutex.Unlock()
+	if s, ok := r.requeue[crName]; ok && !s.missing {
+		s.missing = true
+		s.failures++
+	}
+}
+
+func (r *handler) Requeue(crName string) time.Duration {
+	r.requeueMutex.Lock()
+	defer r.requeueMutex.Unlock()
+	s, ok := r.requeue[crName]
+	if !ok {
+		return r.policy.poll
+	}
+	return r.policy.next(s)
+}
+
+func (r *handler) Delete(crName string) {
+	r.requeueMutex.Lock()
+	defer r.requeueMutex.Unlock()
+	delete(r.requeue, crName)
+}
+
+// NewReconciler wraps r so that the requeue of a reconciled resource follows
+// the requeue policy of the handler. Resources that no longer exist are
+// pruned from the handler.
+func NewReconciler(h Handler, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		crName := strings.Join([]string{req.Namespace, req.Name}, ".")
+		result, err := r.Reconcile(ctx, req)
+		switch {
+		case err == nil && result == (reconcile.Result{}):
+			// the resource is gone or its deletion completed
+			h.Delete(crName)
+		case result.RequeueAfter > 0:
+			result.RequeueAfter = h.Requeue(crName)
+		}
+		return result, err
+	})
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const crName = "default.nokia"
+
+func newHandler(t *testing.T, poll time.Duration) Handler {
+	t.Helper()
+	h, err := New(WithPollInterval(poll))
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	return h
+}
+
+func TestRequeue(t *testing.T) {
+	const poll = 30 * time.Second
+
+	// step is one reconcile of the resource
+	type step struct {
+		generation int64
+		reset      bool
+		missing    bool
+		want       time.Duration
+	}
+
+	cases := map[string]struct {
+		steps []step
+	}{
+		"FastThenPoll": {
+			steps: []step{
+				{generation: 1, want: 1 * time.Second},
+				{generation: 1, want: 5 * time.Second},
+				{generation: 1, want: 15 * time.Second},
+				{generation: 1, want: poll},
+				{generation: 1, want: poll},
+			},
+		},
+		"SpecChangeRestartsFastSchedule": {
+			steps: []step{
+				{generation: 1, want: 1 * time.Second},
+				{generation: 1, want: 5 * time.Second},
+				{generation: 1, want: 15 * time.Second},
+				{generation: 1, want: poll},
+				{generation: 2, want: 1 * time.Second},
+				{generation: 2, want: 5 * time.Second},
+			},
+		},
+		"ResetRestartsFastSchedule": {
+			steps: []step{
+				{generation: 1, want: 1 * time.Second},
+				{generation: 1, want: 5 * time.Second},
+				{generation: 1, reset: true, want: 1 * time.Second},
+			},
+		},
+		"BackoffWhileDependencyMissing": {
+			steps: []step{
+				{generation: 1, missing: true, want: 1 * time.Second},
+				{generation: 1, missing: true, want: 2 * time.Second},
+				{generation: 1, missing: true, want: 4 * time.Second},
+				{generation: 1, missing: true, want: 8 * time.Second},
+				{generation: 1, missing: true, want: 16 * time.Second},
+				{generation: 1, missing: true, want: poll},
+				{generation: 1, missing: true, want: poll},
+			},
+		},
+		"BackoffEndsWhenDependencyAppears": {
+			steps: []step{
+				{generation: 1, missing: true, want: 1 * time.Second},
+				{generation: 1, missing: true, want: 2 * time.Second},
+				{generation: 1, want: 1 * time.Second},
+				{generation: 1, missing: true, want: 1 * time.Second},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newHandler(t, poll)
+			for i, s := range tc.steps {
+				if s.reset {
+					h.Reset(crName)
+				}
+				h.Observe(crName, s.generation)
+				if s.missing {
+					h.MissingDependency(crName)
+				}
+				if got := h.Requeue(crName); got != s.want {
+					t.Errorf("step %d: Requeue(): want %s, got %s", i, s.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequeueDefaultPollInterval(t *testing.T) {
+	h := newHandler(t, 0)
+	if got := h.Requeue(crName); got != defaultPollInterval {
+		t.Errorf("Requeue() of an unknown resource: want %s, got %s", defaultPollInterval, got)
+	}
+}
+
+func TestDeletePrunesState(t *testing.T) {
+	const poll = 10 * time.Second
+	h := newHandler(t, poll)
+	h.Observe(crName, 1)
+	h.Delete(crName)
+	if got := h.Requeue(crName); got != poll {
+		t.Errorf("Requeue() after Delete(): want %s, got %s", poll, got)
+	}
+	if n := len(h.(*handler).requeue); n != 0 {
+		t.Errorf("Delete(): want no requeue state, got %d entries", n)
+	}
+}
+
+func TestNewReconciler(t *testing.T) {
+	const poll = 10 * time.Second
+	errBoom := errors.New("boom")
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "nokia"}}
+
+	cases := map[string]struct {
+		result     reconcile.Result
+		err        error
+		want       reconcile.Result
+		wantPruned bool
+	}{
+		"RequeueAfterFollowsPolicy": {
+			result: reconcile.Result{RequeueAfter: time.Minute},
+			want:   reconcile.Result{RequeueAfter: 1 * time.Second},
+		},
+		"RequeueIsKept": {
+			result: reconcile.Result{Requeue: true},
+			want:   reconcile.Result{Requeue: true},
+		},
+		"ErrorIsKept": {
+			err:  errBoom,
+			want: reconcile.Result{},
+		},
+		"GoneIsPruned": {
+			want:       reconcile.Result{},
+			wantPruned: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newHandler(t, poll)
+			h.Observe(crName, 1)
+			r := NewReconciler(h, reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+				return tc.result, tc.err
+			}))
+			got, err := r.Reconcile(context.Background(), req)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Reconcile(): want error %v, got %v", tc.err, err)
+			}
+			if got != tc.want {
+				t.Errorf("Reconcile(): want %+v, got %+v", tc.want, got)
+			}
+			_, ok := h.(*handler).requeue[crName]
+			if ok == tc.wantPruned {
+				t.Errorf("Reconcile(): want pruned %t, got %t", tc.wantPruned, !ok)
+			}
+		})
+	}
+}
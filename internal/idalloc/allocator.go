@@ -0,0 +1,154 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package idalloc allocates the numeric IDs of organizations and deployments.
+//
+// The allocated IDs are recorded in the status of the objects, which makes
+// them stable across restarts. The Allocator itself only remembers the IDs
+// that were handed out but are not yet visible in the status of their object,
+// so two objects never get the same ID while the cache catches up. The Table
+// keeps the IDs in a ConfigMap, which makes them unique across replicas.
+package idalloc
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// errors
+	errExhausted = "no free id in scope %q, all ids up to %d are in use"
+)
+
+// A Claim is the ID recorded in the status of an object of a scope.
+type Claim struct {
+	UID types.UID
+	// ID is 0 when the object has no ID yet
+	ID      int64
+	Created metav1.Time
+}
+
+// before returns true when c keeps an ID that is claimed by both c and o, the
+// oldest object keeps it.
+func (c Claim) before(o Claim) bool {
+	if !c.Created.Equal(&o.Created) {
+		return c.Created.Before(&o.Created)
+	}
+	return c.UID < o.UID
+}
+
+// An Allocator hands out the lowest free ID of a scope, starting at 1.
+type Allocator struct {
+	max int64
+
+	m sync.Mutex
+	// pending holds the IDs per scope and object that are not yet recorded
+	// in the status of the object
+	pending map[string]map[types.UID]int64
+}
+
+// New returns an Allocator of the IDs 1 to max.
+func New(max int64) *Allocator {
+	return &Allocator{
+		max:     max,
+		pending: make(map[string]map[types.UID]int64),
+	}
+}
+
+// Allocate returns the ID of owner in scope. claims are the claims of all
+// objects of the scope, the claim of the owner takes precedence over its
+// entry in claims. The recorded ID of the owner is kept, unless an older
+// object claims the same ID.
+func (a *Allocator) Allocate(scope string, owner Claim, claims []Claim) (int64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	// holders are the objects that keep their recorded ID
+	holders := make(map[int64]Claim, len(claims))
+	exists := map[types.UID]bool{owner.UID: true}
+	for _, c := range claims {
+		if c.UID == owner.UID {
+			continue
+		}
+		exists[c.UID] = true
+		if c.ID == 0 {
+			continue
+		}
+		if h, ok := holders[c.ID]; !ok || c.before(h) {
+			holders[c.ID] = c
+		}
+	}
+
+	pending := a.pending[scope]
+	for uid, id := range pending {
+		// the object was deleted or its ID is recorded
+		if !exists[uid] || (uid != owner.UID && holders[id].UID == uid) {
+			delete(pending, uid)
+		}
+	}
+
+	if owner.ID != 0 {
+		if h, ok := holders[owner.ID]; !ok || owner.before(h) {
+			delete(pending, owner.UID)
+			a.cleanup(scope)
+			return owner.ID, nil
+		}
+	}
+
+	taken := make(map[int64]bool, len(holders)+len(pending))
+	for id := range holders {
+		taken[id] = true
+	}
+	for uid, id := range pending {
+		if uid != owner.UID {
+			taken[id] = true
+		}
+	}
+	if id, ok := pending[owner.UID]; ok && !taken[id] {
+		return id, nil
+	}
+
+	for id := int64(1); id <= a.max; id++ {
+		if taken[id] {
+			continue
+		}
+		if pending == nil {
+			pending = make(map[types.UID]int64)
+			a.pending[scope] = pending
+		}
+		pending[owner.UID] = id
+		return id, nil
+	}
+	return 0, fmt.Errorf(errExhausted, scope, a.max)
+}
+
+// Release forgets the pending ID of owner, e.g. when the owner is deleted
+// before its ID was recorded.
+func (a *Allocator) Release(scope string, owner types.UID) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	delete(a.pending[scope], owner)
+	a.cleanup(scope)
+}
+
+func (a *Allocator) cleanup(scope string) {
+	if len(a.pending[scope]) == 0 {
+		delete(a.pending, scope)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idalloc
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const scope = "nokia"
+
+var epoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func claim(uid string, id int64, age int) Claim {
+	return Claim{UID: types.UID(uid), ID: id, Created: metav1.NewTime(epoch.Add(-time.Duration(age) * time.Hour))}
+}
+
+func TestAllocate(t *testing.T) {
+	cases := map[string]struct {
+		owner  Claim
+		claims []Claim
+		want   int64
+	}{
+		"First": {
+			owner: claim("a", 0, 1),
+			want:  1,
+		},
+		"LowestFree": {
+			owner:  claim("d", 0, 1),
+			claims: []Claim{claim("a", 1, 3), claim("b", 3, 2), claim("d", 0, 1)},
+			want:   2,
+		},
+		"KeepsRecorded": {
+			owner:  claim("b", 7, 1),
+			claims: []Claim{claim("a", 1, 3), claim("b", 7, 1)},
+			want:   7,
+		},
+		"OlderKeepsDuplicate": {
+			owner:  claim("a", 1, 3),
+			claims: []Claim{claim("a", 1, 3), claim("b", 1, 1)},
+			want:   1,
+		},
+		"YoungerLosesDuplicate": {
+			owner:  claim("b", 1, 1),
+			claims: []Claim{claim("a", 1, 3), claim("b", 1, 1)},
+			want:   2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(10).Allocate(scope, tc.owner, tc.claims)
+			if err != nil {
+				t.Fatalf("Allocate(): %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Allocate(): want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAllocatePending(t *testing.T) {
+	a := New(10)
+	owner, other := claim("a", 0, 2), claim("b", 0, 1)
+
+	// the ids are not recorded yet, the allocator remembers them
+	idA, _ := a.Allocate(scope, owner, []Claim{owner, other})
+	idB, _ := a.Allocate(scope, other, []Claim{owner, other})
+	if idA != 1 || idB != 2 {
+		t.Fatalf("Allocate(): want 1 and 2, got %d and %d", idA, idB)
+	}
+	if id, _ := a.Allocate(scope, owner, []Claim{owner, other}); id != idA {
+		t.Errorf("Allocate(): want the pending id %d again, got %d", idA, id)
+	}
+
+	// the ids are recorded
+	owner.ID, other.ID = idA, idB
+	if id, _ := a.Allocate(scope, owner, []Claim{owner, other}); id != idA {
+		t.Errorf("Allocate(): want the recorded id %d, got %d", idA, id)
+	}
+	if len(a.pending) != 0 {
+		t.Errorf("Allocate(): want no pending ids once recorded, got %v", a.pending)
+	}
+
+	// the id of a deleted object is free again
+	third := claim("c", 0, 0)
+	if id, _ := a.Allocate(scope, third, []Claim{owner, third}); id != 2 {
+		t.Errorf("Allocate(): want the id of the deleted object, got %d", id)
+	}
+}
+
+func TestAllocateScopes(t *testing.T) {
+	a := New(10)
+	owner := claim("a", 0, 1)
+	if id, _ := a.Allocate("nokia", owner, nil); id != 1 {
+		t.Errorf("Allocate(nokia): want 1, got %d", id)
+	}
+	if id, _ := a.Allocate("acme", claim("b", 0, 1), nil); id != 1 {
+		t.Errorf("Allocate(acme): want 1, the scopes are independent, got %d", id)
+	}
+	a.Release("nokia", owner.UID)
+	if len(a.pending["nokia"]) != 0 {
+		t.Errorf("Release(): want no pending ids, got %v", a.pending["nokia"])
+	}
+}
+
+func TestAllocateExhausted(t *testing.T) {
+	a := New(2)
+	claims := []Claim{claim("a", 1, 3), claim("b", 2, 2)}
+	if _, err := a.Allocate(scope, claim("c", 0, 1), claims); err == nil {
+		t.Error("Allocate(): want an error when all ids are in use")
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idalloc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// errors
+	errNoTable     = "the id table requires a namespace and a name"
+	errGetTable    = "cannot get id table"
+	errCreateTable = "cannot create id table"
+	errUpdateTable = "cannot update id table"
+)
+
+// A Table allocates the IDs of a scope in a ConfigMap that maps every ID to
+// the UID of its object. The ConfigMap is written with optimistic
+// concurrency, two replicas that allocate at the same time conflict and one
+// of them retries, so an ID is never handed out twice.
+//
+// A ConfigMap holds at most 1MiB, which is about 20000 IDs. The ID of an
+// object that is deleted without releasing it stays allocated.
+type Table struct {
+	client client.Client
+	// reader is expected to be a cache, a stale ConfigMap conflicts on update
+	reader client.Reader
+	key    types.NamespacedName
+	labels map[string]string
+	max    int64
+}
+
+// NewTable returns a Table of the IDs 1 to max in the ConfigMap key. The
+// ConfigMap is created with the supplied labels.
+func NewTable(c client.Client, r client.Reader, key types.NamespacedName, labels map[string]string, max int64) *Table {
+	return &Table{
+		client: c,
+		reader: r,
+		key:    key,
+		labels: labels,
+		max:    max,
+	}
+}
+
+// Allocate returns the ID of owner. The ID the table holds for owner is kept,
+// otherwise the recorded ID of owner is taken when it is free, e.g. an ID
+// that was allocated before the table existed, otherwise the lowest free ID.
+func (t *Table) Allocate(ctx context.Context, owner types.UID, recorded int64) (int64, error) {
+	cm, err := t.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := make(map[int64]bool, len(cm.Data))
+	for key, uid := range cm.Data {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uid == string(owner) {
+			return id, nil
+		}
+		taken[id] = true
+	}
+
+	id := recorded
+	if id < 1 || id > t.max || taken[id] {
+		id = 0
+		for i := int64(1); i <= t.max; i++ {
+			if !taken[i] {
+				id = i
+				break
+			}
+		}
+	}
+	if id == 0 {
+		return 0, fmt.Errorf(errExhausted, t.key.Name, t.max)
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[strconv.FormatInt(id, 10)] = string(owner)
+	if err := t.write(ctx, cm); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Release frees the IDs the table holds for owner.
+func (t *Table) Release(ctx context.Context, owner types.UID) error {
+	cm, err := t.get(ctx)
+	if err != nil {
+		return err
+	}
+	released := false
+	for key, uid := range cm.Data {
+		if uid == string(owner) {
+			delete(cm.Data, key)
+			released = true
+		}
+	}
+	if !released {
+		return nil
+	}
+	return t.write(ctx, cm)
+}
+
+// get returns the ConfigMap of the table, a ConfigMap that does not exist
+// yet has no resource version.
+func (t *Table) get(ctx context.Context) (*corev1.ConfigMap, error) {
+	if t.key.Namespace == "" || t.key.Name == "" {
+		return nil, errors.New(errNoTable)
+	}
+	cm := &corev1.ConfigMap{}
+	err := t.reader.Get(ctx, t.key, cm)
+	switch {
+	case kerrors.IsNotFound(err):
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: t.key.Namespace,
+			Name:      t.key.Name,
+			Labels:    t.labels,
+		}}, nil
+	case err != nil:
+		return nil, errors.Wrap(err, errGetTable)
+	}
+	return cm, nil
+}
+
+// write creates or updates the ConfigMap of the table, the update fails when
+// the ConfigMap changed since it was read.
+func (t *Table) write(ctx context.Context, cm *corev1.ConfigMap) error {
+	if cm.GetResourceVersion() == "" {
+		return errors.Wrap(t.client.Create(ctx, cm), errCreateTable)
+	}
+	return errors.Wrap(t.client.Update(ctx, cm), errUpdateTable)
+}
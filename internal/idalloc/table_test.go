@@ -0,0 +1,181 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idalloc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var tableKey = types.NamespacedName{Namespace: "ndd-system", Name: "organization-ids"}
+
+func tableOf(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: tableKey.Namespace, Name: tableKey.Name},
+		Data:       data,
+	}
+}
+
+func TestTableAllocate(t *testing.T) {
+	cases := map[string]struct {
+		table    *corev1.ConfigMap
+		owner    string
+		recorded int64
+		want     int64
+		wantData map[string]string
+	}{
+		"CreatesTable": {
+			owner:    "a",
+			want:     1,
+			wantData: map[string]string{"1": "a"},
+		},
+		"LowestFree": {
+			table:    tableOf(map[string]string{"1": "a", "3": "b"}),
+			owner:    "c",
+			want:     2,
+			wantData: map[string]string{"1": "a", "2": "c", "3": "b"},
+		},
+		"KeepsAllocated": {
+			table:    tableOf(map[string]string{"1": "a", "2": "b"}),
+			owner:    "b",
+			recorded: 5,
+			want:     2,
+			wantData: map[string]string{"1": "a", "2": "b"},
+		},
+		"ClaimsFreeRecorded": {
+			table:    tableOf(map[string]string{"1": "a"}),
+			owner:    "b",
+			recorded: 7,
+			want:     7,
+			wantData: map[string]string{"1": "a", "7": "b"},
+		},
+		"ReplacesTakenRecorded": {
+			table:    tableOf(map[string]string{"1": "a"}),
+			owner:    "b",
+			recorded: 1,
+			want:     2,
+			wantData: map[string]string{"1": "a", "2": "b"},
+		},
+		"ReplacesOutOfRangeRecorded": {
+			owner:    "a",
+			recorded: 11,
+			want:     1,
+			wantData: map[string]string{"1": "a"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newFakeClient(tc.table)
+			table := NewTable(c, c, tableKey, map[string]string{"app": "test"}, 10)
+
+			got, err := table.Allocate(context.Background(), types.UID(tc.owner), tc.recorded)
+			if err != nil {
+				t.Fatalf("Allocate(...): unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Allocate(...): want %d, got %d", tc.want, got)
+			}
+			cm := &corev1.ConfigMap{}
+			if err := c.Get(context.Background(), tableKey, cm); err != nil {
+				t.Fatalf("Get(...): unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cm.Data, tc.wantData) {
+				t.Errorf("Allocate(...): want table %v, got %v", tc.wantData, cm.Data)
+			}
+		})
+	}
+}
+
+func TestTableExhausted(t *testing.T) {
+	c := newFakeClient(tableOf(map[string]string{"1": "a", "2": "b"}))
+	table := NewTable(c, c, tableKey, nil, 2)
+	if _, err := table.Allocate(context.Background(), "c", 0); err == nil {
+		t.Errorf("Allocate(...): want error, got nil")
+	}
+}
+
+func TestTableStaleRead(t *testing.T) {
+	c := newFakeClient(tableOf(map[string]string{"1": "a"}))
+	stale := &corev1.ConfigMap{}
+	if err := c.Get(context.Background(), tableKey, stale); err != nil {
+		t.Fatalf("Get(...): unexpected error: %v", err)
+	}
+
+	// another replica allocates after the cache of this replica was filled
+	if _, err := NewTable(c, c, tableKey, nil, 10).Allocate(context.Background(), "b", 0); err != nil {
+		t.Fatalf("Allocate(...): unexpected error: %v", err)
+	}
+
+	table := NewTable(c, staleReader{stale}, tableKey, nil, 10)
+	_, err := table.Allocate(context.Background(), "c", 0)
+	if !kerrors.IsConflict(errors.Cause(err)) {
+		t.Errorf("Allocate(...): want conflict, got %v", err)
+	}
+}
+
+func TestTableRelease(t *testing.T) {
+	c := newFakeClient(tableOf(map[string]string{"1": "a", "2": "b"}))
+	table := NewTable(c, c, tableKey, nil, 10)
+
+	if err := table.Release(context.Background(), "a"); err != nil {
+		t.Fatalf("Release(...): unexpected error: %v", err)
+	}
+	if err := table.Release(context.Background(), "unknown"); err != nil {
+		t.Fatalf("Release(...): unexpected error: %v", err)
+	}
+	got, err := table.Allocate(context.Background(), "c", 0)
+	if err != nil {
+		t.Fatalf("Allocate(...): unexpected error: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("Allocate(...): want released id 1, got %d", got)
+	}
+}
+
+func newFakeClient(objs ...*corev1.ConfigMap) client.Client {
+	b := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme)
+	for _, o := range objs {
+		if o != nil {
+			b = b.WithObjects(o)
+		}
+	}
+	return b.Build()
+}
+
+// staleReader returns a ConfigMap that was read before the last update.
+type staleReader struct {
+	cm *corev1.ConfigMap
+}
+
+func (r staleReader) Get(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+	r.cm.DeepCopyInto(obj.(*corev1.ConfigMap))
+	return nil
+}
+
+func (r staleReader) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pause implements the paused and reconcile request annotations of
+// organizations and deployments.
+package pause
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/resource"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// errors
+	errGetObject           = "cannot get object"
+	errUpdatePaused        = "cannot update paused condition"
+	errAckReconcileRequest = "cannot remove reconcile request annotation"
+)
+
+// Object is an organization or deployment.
+type Object interface {
+	client.Object
+	resource.Conditioned
+}
+
+// NewReconciler wraps r so that paused objects are not reconciled, unless
+// they are deleted, and reconcile requests restart the requeue schedule of
+// the handler.
+func NewReconciler(c client.Client, h handler.Handler, newObj func() Object, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		cr := newObj()
+		if err := c.Get(ctx, req.NamespacedName, cr); err != nil {
+			// let the reconciler handle a missing object
+			if resource.IgnoreNotFound(err) == nil {
+				return r.Reconcile(ctx, req)
+			}
+			return reconcile.Result{}, errors.Wrap(err, errGetObject)
+		}
+
+		// a deleted object is reconciled even when paused, otherwise its
+		// finalizer is never removed
+		if orgv1alpha1.IsPaused(cr) && cr.GetDeletionTimestamp().IsZero() {
+			if !isPaused(cr) {
+				cr.SetConditions(orgv1alpha1.Paused())
+				if err := c.Status().Update(ctx, cr); err != nil {
+					return reconcile.Result{}, errors.Wrap(err, errUpdatePaused)
+				}
+			}
+			// a paused object is only reconciled again when unpaused
+			return reconcile.Result{}, nil
+		}
+
+		if isPaused(cr) {
+			cr.SetConditions(orgv1alpha1.Unpaused())
+			if err := c.Status().Update(ctx, cr); err != nil {
+				return reconcile.Result{}, errors.Wrap(err, errUpdatePaused)
+			}
+		}
+
+		if orgv1alpha1.HasReconcileRequest(cr) {
+			h.Reset(strings.Join([]string{req.Namespace, req.Name}, "."))
+			patch := client.MergeFrom(cr.DeepCopyObject().(client.Object))
+			annotations := cr.GetAnnotations()
+			delete(annotations, orgv1alpha1.AnnotationReconcileRequest)
+			cr.SetAnnotations(annotations)
+			if err := c.Patch(ctx, cr, patch); err != nil {
+				return reconcile.Result{}, errors.Wrap(err, errAckReconcileRequest)
+			}
+		}
+
+		return r.Reconcile(ctx, req)
+	})
+}
+
+func isPaused(cr Object) bool {
+	return cr.GetCondition(orgv1alpha1.ConditionKindPaused).Status == corev1.ConditionTrue
+}
+
+// Predicate passes updates that pause or unpause an object or request a
+// reconcile, these do not change the generation of the object.
+func Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if orgv1alpha1.IsPaused(e.ObjectOld) != orgv1alpha1.IsPaused(e.ObjectNew) {
+				return true
+			}
+			return orgv1alpha1.HasReconcileRequest(e.ObjectNew) &&
+				e.ObjectOld.GetAnnotations()[orgv1alpha1.AnnotationReconcileRequest] !=
+					e.ObjectNew.GetAnnotations()[orgv1alpha1.AnnotationReconcileRequest]
+		},
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pause
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/handler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resetRecorder records the names the requeue schedule is reset for.
+type resetRecorder struct {
+	handler.Handler
+	reset []string
+}
+
+func (h *resetRecorder) Reset(crName string) {
+	h.reset = append(h.reset, crName)
+}
+
+func TestNewReconciler(t *testing.T) {
+	now := metav1.NewTime(time.Now())
+	key := types.NamespacedName{Namespace: "default", Name: "nokia"}
+
+	cases := map[string]struct {
+		obj           *orgv1alpha1.Organization
+		wantReconcile bool
+		wantPaused    corev1.ConditionStatus
+		wantReset     []string
+	}{
+		"NotFound": {
+			wantReconcile: true,
+		},
+		"Paused": {
+			obj: &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   key.Namespace,
+				Name:        key.Name,
+				Annotations: map[string]string{orgv1alpha1.AnnotationPaused: "true"},
+			}},
+			wantReconcile: false,
+			wantPaused:    corev1.ConditionTrue,
+		},
+		"PausedDeleted": {
+			obj: &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{
+				Namespace:         key.Namespace,
+				Name:              key.Name,
+				Annotations:       map[string]string{orgv1alpha1.AnnotationPaused: "true"},
+				DeletionTimestamp: &now,
+				Finalizers:        []string{"finalizer.nddo.yndd.io"},
+			}},
+			wantReconcile: true,
+			wantPaused:    corev1.ConditionUnknown,
+		},
+		"Unpaused": {
+			obj: func() *orgv1alpha1.Organization {
+				o := &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+				o.SetConditions(orgv1alpha1.Paused())
+				return o
+			}(),
+			wantReconcile: true,
+			wantPaused:    corev1.ConditionFalse,
+		},
+		"ReconcileRequest": {
+			obj: &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   key.Namespace,
+				Name:        key.Name,
+				Annotations: map[string]string{orgv1alpha1.AnnotationReconcileRequest: "now"},
+			}},
+			wantReconcile: true,
+			wantPaused:    corev1.ConditionUnknown,
+			wantReset:     []string{"default.nokia"},
+		},
+		"PausedReconcileRequest": {
+			obj: &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Annotations: map[string]string{
+					orgv1alpha1.AnnotationPaused:           "true",
+					orgv1alpha1.AnnotationReconcileRequest: "now",
+				},
+			}},
+			wantReconcile: false,
+			wantPaused:    corev1.ConditionTrue,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			b := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.obj != nil {
+				b = b.WithObjects(tc.obj)
+			}
+			c := b.Build()
+			h := &resetRecorder{}
+
+			reconciled := false
+			inner := reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+				reconciled = true
+				return reconcile.Result{}, nil
+			})
+			newObj := func() Object { return &orgv1alpha1.Organization{} }
+
+			if _, err := NewReconciler(c, h, newObj, inner).Reconcile(context.Background(), reconcile.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile(...): %v", err)
+			}
+			if reconciled != tc.wantReconcile {
+				t.Errorf("Reconcile(...): reconciled %t, want %t", reconciled, tc.wantReconcile)
+			}
+			if diff := cmp.Diff(tc.wantReset, h.reset); diff != "" {
+				t.Errorf("Reset(...): -want, +got:\n%s", diff)
+			}
+			if tc.obj == nil {
+				return
+			}
+
+			got := &orgv1alpha1.Organization{}
+			if err := c.Get(context.Background(), key, got); err != nil {
+				t.Fatalf("Get(...): %v", err)
+			}
+			if s := got.GetCondition(orgv1alpha1.ConditionKindPaused).Status; s != tc.wantPaused {
+				t.Errorf("Paused condition: got %s, want %s", s, tc.wantPaused)
+			}
+			if tc.wantReset != nil && orgv1alpha1.HasReconcileRequest(got) {
+				t.Errorf("reconcile request annotation not removed")
+			}
+		})
+	}
+}
+
+func TestPredicate(t *testing.T) {
+	org := func(annotations map[string]string) *orgv1alpha1.Organization {
+		return &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "nokia", Annotations: annotations}}
+	}
+
+	cases := map[string]struct {
+		old  map[string]string
+		new  map[string]string
+		want bool
+	}{
+		"Unchanged": {
+			want: false,
+		},
+		"Paused": {
+			new:  map[string]string{orgv1alpha1.AnnotationPaused: "true"},
+			want: true,
+		},
+		"Unpaused": {
+			old:  map[string]string{orgv1alpha1.AnnotationPaused: "true"},
+			want: true,
+		},
+		"ReconcileRequest": {
+			new:  map[string]string{orgv1alpha1.AnnotationReconcileRequest: "1"},
+			want: true,
+		},
+		"SameReconcileRequest": {
+			old:  map[string]string{orgv1alpha1.AnnotationReconcileRequest: "1"},
+			new:  map[string]string{orgv1alpha1.AnnotationReconcileRequest: "1"},
+			want: false,
+		},
+		"ReconcileRequestRemoved": {
+			old:  map[string]string{orgv1alpha1.AnnotationReconcileRequest: "1"},
+			want: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Predicate().Update(event.UpdateEvent{ObjectOld: org(tc.old), ObjectNew: org(tc.new)})
+			if got != tc.want {
+				t.Errorf("Update(...): got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryserver
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	pathPrefix        = "/api/v1/"
+	pathOrganizations = pathPrefix + "organizations"
+	pathDeployments   = pathPrefix + "deployments"
+	pathRegisters     = pathPrefix + "registers/"
+	pathOpenAPI       = pathPrefix + "openapi.json"
+
+	shutdownTimeout = 5 * time.Second
+
+	// errors
+	errListen = "cannot listen on query address"
+)
+
+//go:embed openapi.json
+var openapi []byte
+
+func New(address string, opts ...Option) Server {
+	s := &server{
+		address: address,
+		log:     logging.NewNopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type server struct {
+	address string
+	log     logging.Logger
+	// kubernetes
+	client   client.Client
+	registry registry.Registry
+}
+
+func (s *server) WithLogger(log logging.Logger) {
+	s.log = log
+}
+
+func (s *server) WithClient(c client.Client) {
+	s.client = c
+}
+
+func (s *server) WithRegistry(r registry.Registry) {
+	s.registry = r
+}
+
+// NeedLeaderElection returns false, every replica serves the api from its
+// own cache.
+func (s *server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *server) Start(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return errors.Wrap(err, errListen)
+	}
+	hs := &http.Server{Handler: s.handler()}
+
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		hs.Shutdown(sctx) // nolint:errcheck
+	}()
+
+	s.log.Debug("query server started", "address", s.address)
+	if err := hs.Serve(l); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pathOrganizations, s.get(s.listOrganizations))
+	mux.HandleFunc(pathDeployments, s.get(s.listDeployments))
+	mux.HandleFunc(pathRegisters, s.get(s.getRegister))
+	mux.HandleFunc(pathOpenAPI, s.get(func(r *http.Request) (interface{}, error) {
+		return json.RawMessage(openapi), nil
+	}))
+	return mux
+}
+
+// Organization is the json representation of an organization.
+type Organization struct {
+	Namespace                 string                            `json:"namespace"`
+	Name                      string                            `json:"name"`
+	Organization              string                            `json:"organization"`
+	Description               string                            `json:"description,omitempty"`
+	Status                    string                            `json:"status"`
+	Reason                    string                            `json:"reason,omitempty"`
+	Register                  map[string]string                 `json:"register"`
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+}
+
+// Deployment is the json representation of a deployment.
+type Deployment struct {
+	Namespace                 string                            `json:"namespace"`
+	Name                      string                            `json:"name"`
+	Organization              string                            `json:"organization"`
+	Deployment                string                            `json:"deployment"`
+	Description               string                            `json:"description,omitempty"`
+	Kind                      string                            `json:"kind,omitempty"`
+	Region                    string                            `json:"region,omitempty"`
+	AdminState                string                            `json:"admin-state,omitempty"`
+	Status                    string                            `json:"status"`
+	Reason                    string                            `json:"reason,omitempty"`
+	Register                  map[string]string                 `json:"register"`
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+}
+
+// Register is the json representation of the effective register of an
+// organization or deployment.
+type Register struct {
+	Namespace                 string                            `json:"namespace"`
+	Name                      string                            `json:"name"`
+	Register                  map[string]string                 `json:"register"`
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy `json:"address-allocation-strategy,omitempty"`
+}
+
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// get serves the result of fn as json and supports conditional requests
+// through an ETag derived from the response body.
+func (s *server) get(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeJSON(w, http.StatusMethodNotAllowed, &httpError{Error: "method not allowed"})
+			return
+		}
+		v, err := fn(r)
+		if err != nil {
+			s.log.Debug("query failed", "path", r.URL.Path, "error", err)
+			writeJSON(w, toHTTPStatus(err), &httpError{Error: err.Error()})
+			return
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, &httpError{Error: err.Error()})
+			return
+		}
+		sum := sha256.Sum256(b)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		if match := r.Header.Get("If-None-Match"); match != "" && etagMatch(match, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(b) // nolint:errcheck
+		}
+	}
+}
+
+// listOrganizations supports the namespace and organization query parameters.
+func (s *server) listOrganizations(r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	orgs := &orgv1alpha1.OrganizationList{}
+	if err := s.client.List(r.Context(), orgs, listOptions(q.Get("namespace"))...); err != nil {
+		return nil, err
+	}
+	result := make([]*Organization, 0, len(orgs.Items))
+	for _, org := range orgs.GetOrganizations() {
+		if !matches(q.Get("organization"), org.GetOrganizationName()) {
+			continue
+		}
+		result = append(result, &Organization{
+			Namespace:                 org.GetNamespace(),
+			Name:                      org.GetName(),
+			Organization:              org.GetOrganizationName(),
+			Description:               org.GetDescription(),
+			Status:                    org.GetStatus(),
+			Reason:                    org.GetReason(),
+			Register:                  org.GetStateRegister(),
+			AddressAllocationStrategy: org.GetStateAddressAllocationStrategy(),
+		})
+	}
+	// the cache lists in no particular order, the ETag depends on the order
+	sort.Slice(result, func(i, j int) bool {
+		return less(result[i].Namespace, result[i].Name, result[j].Namespace, result[j].Name)
+	})
+	return result, nil
+}
+
+// listDeployments supports the namespace, organization, kind, region,
+// admin-state and status query parameters.
+func (s *server) listDeployments(r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	deps := &orgv1alpha1.DeploymentList{}
+	if err := s.client.List(r.Context(), deps, listOptions(q.Get("namespace"))...); err != nil {
+		return nil, err
+	}
+	result := make([]*Deployment, 0, len(deps.Items))
+	for _, dep := range deps.GetDeployments() {
+		if !matches(q.Get("organization"), dep.GetOrganizationName()) ||
+			!matches(q.Get("kind"), dep.GetKind()) ||
+			!matches(q.Get("region"), dep.GetRegion()) ||
+			!matches(q.Get("admin-state"), dep.GetAdminState()) ||
+			!matches(q.Get("status"), dep.GetStatus()) {
+			continue
+		}
+		result = append(result, &Deployment{
+			Namespace:                 dep.GetNamespace(),
+			Name:                      dep.GetName(),
+			Organization:              dep.GetOrganizationName(),
+			Deployment:                dep.GetDeploymentName(),
+			Description:               dep.GetDescription(),
+			Kind:                      dep.GetKind(),
+			Region:                    dep.GetRegion(),
+			AdminState:                dep.GetAdminState(),
+			Status:                    dep.GetStatus(),
+			Reason:                    dep.GetReason(),
+			Register:                  dep.GetStateRegister(),
+			AddressAllocationStrategy: dep.GetStateAddressAllocationStrategy(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return less(result[i].Namespace, result[i].Name, result[j].Namespace, result[j].Name)
+	})
+	return result, nil
+}
+
+// getRegister serves /api/v1/registers/<namespace>/<odns name>.
+func (s *server) getRegister(r *http.Request) (interface{}, error) {
+	split := strings.Split(strings.TrimPrefix(r.URL.Path, pathRegisters), "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return nil, errBadRequest("expected " + pathRegisters + "<namespace>/<name>")
+	}
+	namespace, name := split[0], split[1]
+	register, err := s.registry.GetRegisterByName(r.Context(), namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	aas, err := s.registry.GetAddressAllocationStrategyByName(r.Context(), namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Register{
+		Namespace:                 namespace,
+		Name:                      name,
+		Register:                  register,
+		AddressAllocationStrategy: aas,
+	}, nil
+}
+
+type errBadRequest string
+
+func (e errBadRequest) Error() string {
+	return string(e)
+}
+
+func toHTTPStatus(err error) int {
+	var br errBadRequest
+	switch {
+	case errors.As(err, &br):
+		return http.StatusBadRequest
+	case kerrors.IsNotFound(err):
+		return http.StatusNotFound
+	case registry.IsCriticalRegisterError(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func listOptions(namespace string) []client.ListOption {
+	if namespace == "" {
+		return nil
+	}
+	return []client.ListOption{client.InNamespace(namespace)}
+}
+
+// less orders list items by namespace and name.
+func less(ns1, name1, ns2, name2 string) bool {
+	if ns1 != ns2 {
+		return ns1 < ns2
+	}
+	return name1 < name2
+}
+
+// matches returns true if no filter is set or the value equals the filter.
+func matches(filter, value string) bool {
+	return filter == "" || filter == value
+}
+
+func etagMatch(header, etag string) bool {
+	for _, m := range strings.Split(header, ",") {
+		m = strings.TrimSpace(m)
+		if m == "*" || strings.TrimPrefix(m, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v) // nolint:errcheck
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryserver
+
+import (
+	"context"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Option can be used to manipulate Options.
+type Option func(Server)
+
+// WithLogger specifies how the Server should log messages.
+func WithLogger(log logging.Logger) Option {
+	return func(s Server) {
+		s.WithLogger(log)
+	}
+}
+
+// WithClient specifies the client the Server reads organizations and
+// deployments with, typically the cache backed client of the manager.
+func WithClient(c client.Client) Option {
+	return func(s Server) {
+		s.WithClient(c)
+	}
+}
+
+// WithRegistry specifies the registry the Server resolves registers with.
+func WithRegistry(r registry.Registry) Option {
+	return func(s Server) {
+		s.WithRegistry(r)
+	}
+}
+
+type Server interface {
+	WithLogger(logging.Logger)
+	WithClient(client.Client)
+	WithRegistry(registry.Registry)
+	// Start serves the api until the context is cancelled.
+	Start(ctx context.Context) error
+	NeedLeaderElection() bool
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/pkg/registry/registrytest"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func deployment(namespace, name, kind string) *orgv1alpha1.Deployment {
+	return &orgv1alpha1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: orgv1alpha1.DeploymentSpec{
+			Properties: orgv1alpha1.DeploymentProperties{Kind: utils.StringPtr(kind)},
+		},
+	}
+}
+
+func TestListOrganizations(t *testing.T) {
+	c := newClient(t,
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "nokia"}},
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}},
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme"}},
+	)
+
+	cases := map[string]struct {
+		query string
+		want  []string
+	}{
+		"All": {
+			want: []string{"default/acme", "default/nokia", "other/nokia"},
+		},
+		"Namespace": {
+			query: "?namespace=default",
+			want:  []string{"default/acme", "default/nokia"},
+		},
+		"Organization": {
+			query: "?organization=nokia",
+			want:  []string{"default/nokia", "other/nokia"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := New("", WithClient(c), WithRegistry(registrytest.New())).(*server)
+			rec := httptest.NewRecorder()
+			s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pathOrganizations+tc.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s: code %d, want %d", tc.query, rec.Code, http.StatusOK)
+			}
+			var orgs []*Organization
+			if err := json.Unmarshal(rec.Body.Bytes(), &orgs); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(orgs))
+			for _, org := range orgs {
+				got = append(got, org.Namespace+"/"+org.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GET %s: -want, +got:\n%s", tc.query, diff)
+			}
+		})
+	}
+}
+
+func TestListDeployments(t *testing.T) {
+	c := newClient(t,
+		deployment("default", "nokia.region2", "wan"),
+		deployment("default", "nokia.region1", "dc"),
+		deployment("default", "acme.region1", "dc"),
+		deployment("other", "nokia.region3", "dc"),
+	)
+
+	cases := map[string]struct {
+		query string
+		want  []string
+	}{
+		"All": {
+			want: []string{"default/acme.region1", "default/nokia.region1", "default/nokia.region2", "other/nokia.region3"},
+		},
+		"Namespace": {
+			query: "?namespace=default",
+			want:  []string{"default/acme.region1", "default/nokia.region1", "default/nokia.region2"},
+		},
+		"OrganizationAndKind": {
+			query: "?organization=nokia&kind=dc",
+			want:  []string{"default/nokia.region1", "other/nokia.region3"},
+		},
+		"Status": {
+			query: "?status=up",
+			want:  []string{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := New("", WithClient(c), WithRegistry(registrytest.New())).(*server)
+			rec := httptest.NewRecorder()
+			s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pathDeployments+tc.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s: code %d, want %d", tc.query, rec.Code, http.StatusOK)
+			}
+			var deps []*Deployment
+			if err := json.Unmarshal(rec.Body.Bytes(), &deps); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(deps))
+			for _, dep := range deps {
+				got = append(got, dep.Namespace+"/"+dep.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GET %s: -want, +got:\n%s", tc.query, diff)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	c := newClient(t,
+		deployment("default", "nokia.region2", "dc"),
+		deployment("default", "nokia.region1", "dc"),
+	)
+	h := New("", WithClient(c), WithRegistry(registrytest.New())).(*server).handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pathDeployments, nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET: code %d, etag %q, want %d and an etag", rec.Code, etag, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pathDeployments, nil))
+	if got := rec.Header().Get("ETag"); got != etag {
+		t.Errorf("GET: etag %q, want the etag %q of the identical previous request", got, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, pathDeployments, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("GET If-None-Match: code %d with %d bytes, want %d without a body", rec.Code, rec.Body.Len(), http.StatusNotModified)
+	}
+
+	if err := c.Create(req.Context(), deployment("default", "nokia.region3", "dc")); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("GET If-None-Match after a change: code %d, want %d with a new etag", rec.Code, http.StatusOK)
+	}
+}
+
+func TestGetRegister(t *testing.T) {
+	full := map[string]string{"ipam": "nokia-ipam", "as": "nokia-as", "ni": "nokia-ni"}
+
+	cases := map[string]struct {
+		opts []registrytest.Option
+		path string
+		want map[string]string
+		code int
+	}{
+		"Register": {
+			opts: []registrytest.Option{registrytest.WithRegister("nokia", full)},
+			path: pathRegisters + "default/nokia",
+			want: full,
+			code: http.StatusOK,
+		},
+		"BadRequest": {
+			path: pathRegisters + "default",
+			code: http.StatusBadRequest,
+		},
+		"NotFound": {
+			opts: []registrytest.Option{registrytest.WithError(registrytest.MethodGetRegister,
+				kerrors.NewNotFound(schema.GroupResource{Group: orgv1alpha1.Group, Resource: "organizations"}, "nokia"))},
+			path: pathRegisters + "default/nokia",
+			code: http.StatusNotFound,
+		},
+		"MissingCriticalRegister": {
+			opts: []registrytest.Option{registrytest.WithRegister("nokia", map[string]string{"ipam": "nokia-ipam"})},
+			path: pathRegisters + "default/nokia",
+			code: http.StatusConflict,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := New("", WithRegistry(registrytest.New(tc.opts...))).(*server)
+			rec := httptest.NewRecorder()
+			s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.code {
+				t.Fatalf("GET %s: code %d, want %d: %s", tc.path, rec.Code, tc.code, rec.Body.String())
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			reg := &Register{}
+			if err := json.Unmarshal(rec.Body.Bytes(), reg); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, reg.Register); diff != "" {
+				t.Errorf("GET %s: -want, +got:\n%s", tc.path, diff)
+			}
+		})
+	}
+}
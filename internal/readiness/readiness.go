@@ -0,0 +1,162 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package readiness provides the readiness checks of the org registry
+// manager.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	checkTimeout = 2 * time.Second
+
+	// errors
+	errCacheNotSynced   = "informer caches are not synced"
+	errNotReconciled    = "no successful reconcile yet"
+	errDiscoverRegister = "cannot discover register backend"
+	errDialRegister     = "cannot reach register backend"
+)
+
+// CacheSynced returns a check that passes once the informers of the cache
+// are synced.
+func CacheSynced(c cache.Cache) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		if !c.WaitForCacheSync(ctx) {
+			return errors.New(errCacheNotSynced)
+		}
+		return nil
+	}
+}
+
+// RegisterBackend returns a check that discovers the backend of the supplied
+// register kind the same way GetRegistryClient does and verifies it accepts
+// connections.
+func RegisterBackend(r registry.Registry, registerName string) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		address, err := r.GetRegistryEndpoint(ctx, registerName)
+		if err != nil {
+			return errors.Wrap(err, errDiscoverRegister)
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return errors.Wrap(err, errDialRegister)
+		}
+		return conn.Close()
+	}
+}
+
+// Reconciles tracks whether the controllers completed a successful
+// reconcile.
+type Reconciles struct {
+	client client.Reader
+
+	m           sync.Mutex
+	controllers map[string]*controller
+}
+
+type controller struct {
+	newList    func() client.ObjectList
+	reconciled bool
+}
+
+// NewReconciles returns a Reconciles that reads the reconciled objects
+// through c, typically the cache backed client of the manager.
+func NewReconciles(c client.Reader) *Reconciles {
+	return &Reconciles{
+		client:      c,
+		controllers: make(map[string]*controller),
+	}
+}
+
+// NewReconciler registers the controller and wraps its reconciler to record
+// the first successful reconcile. newList returns an empty list of the kind
+// the controller reconciles; a controller without objects has nothing to
+// reconcile and is ready. The returned reconciler is r when rs is nil.
+func (rs *Reconciles) NewReconciler(name string, newList func() client.ObjectList, r reconcile.Reconciler) reconcile.Reconciler {
+	if rs == nil {
+		return r
+	}
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	c := &controller{newList: newList}
+	rs.controllers[name] = c
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		result, err := r.Reconcile(ctx, req)
+		if err == nil {
+			rs.m.Lock()
+			c.reconciled = true
+			rs.m.Unlock()
+		}
+		return result, err
+	})
+}
+
+// Check passes once every registered controller completed a successful
+// reconcile or has no objects to reconcile.
+func (rs *Reconciles) Check(req *http.Request) error {
+	ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+	defer cancel()
+
+	var pending []string
+	for name, newList := range rs.pending() {
+		list := newList()
+		if err := rs.client.List(ctx, list, client.Limit(1)); err != nil {
+			return err
+		}
+		if meta.LenList(list) > 0 {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return fmt.Errorf("%s: %v", errNotReconciled, pending)
+	}
+	return nil
+}
+
+// pending returns the controllers without a successful reconcile.
+func (rs *Reconciles) pending() map[string]func() client.ObjectList {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	pending := make(map[string]func() client.ObjectList)
+	for name, c := range rs.controllers {
+		if !c.reconciled {
+			pending[name] = c.newList
+		}
+	}
+	return pending
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	leasePrefix = "nddr-org-registry-shard-"
+
+	defaultLeaseDuration = 15 * time.Second
+	defaultResyncPeriod  = 30 * time.Second
+
+	// errors
+	errNoIdentity   = "sharding requires the identity of the replica"
+	errRenewLease   = "cannot renew shard lease"
+	errListLeases   = "cannot list shard leases"
+	errListObjects  = "cannot list sharded objects"
+	errLabelObject  = "cannot label sharded object"
+	errNoNamespace  = "sharding requires the namespace of the shard leases"
+	errNoReadWriter = "sharding requires a client and a reader"
+)
+
+// New returns a Sharder for the replica with the supplied identity, which
+// keeps its lease in namespace.
+func New(identity, namespace string, opts ...Option) Sharder {
+	s := &sharder{
+		identity:      identity,
+		namespace:     namespace,
+		log:           logging.NewNopLogger(),
+		leaseDuration: defaultLeaseDuration,
+		resyncPeriod:  defaultResyncPeriod,
+		selector:      labels.Everything(),
+		changed:       make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type sharder struct {
+	identity  string
+	namespace string
+	log       logging.Logger
+	// kubernetes
+	client client.Client
+	reader client.Reader
+
+	leaseDuration time.Duration
+	resyncPeriod  time.Duration
+	namespaces    []string
+	selector      labels.Selector
+
+	m       sync.RWMutex
+	members []string
+	// changed is signalled when the membership changed
+	changed chan struct{}
+}
+
+func (s *sharder) WithLogger(log logging.Logger) {
+	s.log = log
+}
+
+func (s *sharder) WithClient(c client.Client) {
+	s.client = c
+}
+
+func (s *sharder) WithReader(r client.Reader) {
+	s.reader = r
+}
+
+func (s *sharder) WithLeaseDuration(d time.Duration) {
+	s.leaseDuration = d
+}
+
+func (s *sharder) WithResyncPeriod(d time.Duration) {
+	s.resyncPeriod = d
+}
+
+func (s *sharder) WithScope(namespaces []string, selector labels.Selector) {
+	s.namespaces = namespaces
+	s.selector = selector
+}
+
+// NeedLeaderElection returns false, every replica is a member of the shard
+// ring.
+func (s *sharder) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *sharder) Members() []string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	members := make([]string, len(s.members))
+	copy(members, s.members)
+	return members
+}
+
+func (s *sharder) Start(ctx context.Context) error {
+	switch {
+	case s.identity == "":
+		return errors.New(errNoIdentity)
+	case s.namespace == "":
+		return errors.New(errNoNamespace)
+	case s.client == nil || s.reader == nil:
+		return errors.New(errNoReadWriter)
+	}
+
+	go s.shard(ctx)
+
+	renew := time.NewTicker(s.leaseDuration / 3)
+	defer renew.Stop()
+	for {
+		if err := s.renew(ctx); err != nil {
+			s.log.Debug(errRenewLease, "error", err)
+		}
+		if err := s.updateMembers(ctx); err != nil {
+			s.log.Debug(errListLeases, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.release()
+			return nil
+		case <-renew.C:
+		}
+	}
+}
+
+// renew creates or renews the lease of the replica.
+func (s *sharder) renew(ctx context.Context) error {
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(s.leaseDuration.Seconds())
+
+	lease := &coordinationv1.Lease{}
+	err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: leasePrefix + s.identity}, lease)
+	switch {
+	case kerrors.IsNotFound(err):
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      leasePrefix + s.identity,
+				Labels:    map[string]string{LabelShardMember: "true"},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		return s.client.Create(ctx, lease)
+	case err != nil:
+		return err
+	}
+	lease.Spec.HolderIdentity = &s.identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+	return s.client.Update(ctx, lease)
+}
+
+// release deletes the lease so the other members reshard right away.
+func (s *sharder) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.leaseDuration)
+	defer cancel()
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+		Namespace: s.namespace,
+		Name:      leasePrefix + s.identity,
+	}}
+	if err := s.client.Delete(ctx, lease); err != nil && !kerrors.IsNotFound(err) {
+		s.log.Debug("cannot release shard lease", "error", err)
+	}
+}
+
+// updateMembers reads the live members from their leases.
+func (s *sharder) updateMembers(ctx context.Context) error {
+	leases := &coordinationv1.LeaseList{}
+	if err := s.reader.List(ctx, leases,
+		client.InNamespace(s.namespace),
+		client.MatchingLabels{LabelShardMember: "true"},
+	); err != nil {
+		return err
+	}
+	members := liveMembers(leases.Items, time.Now())
+
+	s.m.Lock()
+	if reflect.DeepEqual(members, s.members) {
+		s.m.Unlock()
+		return nil
+	}
+	s.members = members
+	s.m.Unlock()
+
+	s.log.Debug("shard membership changed", "members", members)
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// liveMembers returns the sorted holders of the leases that did not expire.
+func liveMembers(leases []coordinationv1.Lease, now time.Time) []string {
+	members := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		spec := lease.Spec
+		if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		expiry := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+		if now.After(expiry) {
+			continue
+		}
+		members = append(members, *spec.HolderIdentity)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// shard assigns the organizations and deployments to the members after every
+// membership change and resync period. Only the coordinator, the first
+// member, labels objects.
+func (s *sharder) shard(ctx context.Context) {
+	resync := time.NewTicker(s.resyncPeriod)
+	defer resync.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+		case <-resync.C:
+		}
+
+		members := s.Members()
+		if len(members) == 0 || members[0] != s.identity {
+			continue
+		}
+		if err := s.assign(ctx, members); err != nil {
+			s.log.Debug("cannot assign shards", "error", err)
+		}
+	}
+}
+
+// assign labels every organization and deployment in scope with its owner.
+func (s *sharder) assign(ctx context.Context, members []string) error {
+	namespaces := s.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, namespace := range namespaces {
+		opts := []client.ListOption{
+			client.InNamespace(namespace),
+			client.MatchingLabelsSelector{Selector: s.selector},
+		}
+
+		orgs := &orgv1alpha1.OrganizationList{}
+		if err := s.reader.List(ctx, orgs, opts...); err != nil {
+			return errors.Wrap(err, errListObjects)
+		}
+		for i := range orgs.Items {
+			if err := s.label(ctx, &orgs.Items[i], members); err != nil {
+				return err
+			}
+		}
+
+		deps := &orgv1alpha1.DeploymentList{}
+		if err := s.reader.List(ctx, deps, opts...); err != nil {
+			return errors.Wrap(err, errListObjects)
+		}
+		for i := range deps.Items {
+			if err := s.label(ctx, &deps.Items[i], members); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// organizationObject is an organization or a deployment.
+type organizationObject interface {
+	client.Object
+	GetOrganizationName() string
+}
+
+// label sets the shard label of the object to the owner of its organization.
+// The organization of a deployment is resolved like the deployment
+// reconciler does, so an organization reference takes precedence over the
+// odns name of the deployment.
+func (s *sharder) label(ctx context.Context, obj organizationObject, members []string) error {
+	owner := Owner(members, obj.GetOrganizationName())
+	if obj.GetLabels()[LabelShard] == owner {
+		return nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[LabelShard] = owner
+	obj.SetLabels(labels)
+	if err := s.client.Patch(ctx, obj, patch); err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, errLabelObject)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Option can be used to manipulate Options.
+type Option func(Sharder)
+
+// WithLogger specifies how the Sharder should log messages.
+func WithLogger(log logging.Logger) Option {
+	return func(s Sharder) {
+		s.WithLogger(log)
+	}
+}
+
+// WithClient specifies the client the Sharder writes leases and shard labels
+// with.
+func WithClient(c client.Client) Option {
+	return func(s Sharder) {
+		s.WithClient(c)
+	}
+}
+
+// WithReader specifies the reader the Sharder lists leases, organizations and
+// deployments with. It has to read from the api server since the cache of a
+// replica only holds its own shard.
+func WithReader(r client.Reader) Option {
+	return func(s Sharder) {
+		s.WithReader(r)
+	}
+}
+
+// WithLeaseDuration specifies how long a member stays in the shard ring after
+// its last lease renewal.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(s Sharder) {
+		s.WithLeaseDuration(d)
+	}
+}
+
+// WithResyncPeriod specifies how often the coordinator verifies the shard
+// labels of all organizations and deployments.
+func WithResyncPeriod(d time.Duration) Option {
+	return func(s Sharder) {
+		s.WithResyncPeriod(d)
+	}
+}
+
+// WithScope restricts the sharded organizations and deployments to the
+// supplied namespaces, all namespaces when empty, and label selector.
+func WithScope(namespaces []string, selector labels.Selector) Option {
+	return func(s Sharder) {
+		s.WithScope(namespaces, selector)
+	}
+}
+
+// A Sharder maintains the membership of a replica in the shard ring through a
+// Lease and, on the coordinating member, assigns organizations and their
+// deployments to members by labelling them.
+type Sharder interface {
+	WithLogger(logging.Logger)
+	WithClient(client.Client)
+	WithReader(client.Reader)
+	WithLeaseDuration(time.Duration)
+	WithResyncPeriod(time.Duration)
+	WithScope(namespaces []string, selector labels.Selector)
+	// Members returns the live members of the shard ring, sorted by name.
+	Members() []string
+	// Start runs the membership and sharding loop until the context is
+	// cancelled.
+	Start(ctx context.Context) error
+	NeedLeaderElection() bool
+}
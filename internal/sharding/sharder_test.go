@@ -0,0 +1,134 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAssign(t *testing.T) {
+	members := []string{"replica-0", "replica-1", "replica-2"}
+	// the deployment name and its organization reference hash to different
+	// owners, the reference decides
+	if Owner(members, "nokia") == Owner(members, "other") {
+		t.Fatalf("nokia and other have the same owner, pick other organizations")
+	}
+
+	scheme := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}},
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"}},
+		&orgv1alpha1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other.dc1"},
+			Spec: orgv1alpha1.DeploymentSpec{Properties: orgv1alpha1.DeploymentProperties{
+				OrganizationRef: &orgv1alpha1.OrganizationReference{Name: "nokia"},
+			}},
+		},
+		&orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other.dc2"}},
+	).Build()
+
+	s := New(members[0], "default", WithClient(c), WithReader(c)).(*sharder)
+	if err := s.assign(context.Background(), members); err != nil {
+		t.Fatalf("assign(...): unexpected error: %v", err)
+	}
+
+	cases := map[string]struct {
+		obj  client.Object
+		name string
+		want string
+	}{
+		"Organization":                {obj: &orgv1alpha1.Organization{}, name: "nokia", want: Owner(members, "nokia")},
+		"DeploymentOdnsName":          {obj: &orgv1alpha1.Deployment{}, name: "nokia.dc1", want: Owner(members, "nokia")},
+		"DeploymentOrganizationRef":   {obj: &orgv1alpha1.Deployment{}, name: "other.dc1", want: Owner(members, "nokia")},
+		"DeploymentOtherOrganization": {obj: &orgv1alpha1.Deployment{}, name: "other.dc2", want: Owner(members, "other")},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			key := types.NamespacedName{Namespace: "default", Name: tc.name}
+			if err := c.Get(context.Background(), key, tc.obj); err != nil {
+				t.Fatalf("Get(...): unexpected error: %v", err)
+			}
+			if got := tc.obj.GetLabels()[LabelShard]; got != tc.want {
+				t.Errorf("assign(...): want shard %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLiveMembers(t *testing.T) {
+	now := time.Now()
+	lease := func(holder string, renewed time.Duration, seconds int32) coordinationv1.Lease {
+		renewTime := metav1.NewMicroTime(now.Add(-renewed))
+		return coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			RenewTime:            &renewTime,
+			LeaseDurationSeconds: &seconds,
+		}}
+	}
+
+	cases := map[string]struct {
+		leases []coordinationv1.Lease
+		want   []string
+	}{
+		"NoLeases": {
+			want: []string{},
+		},
+		"Sorted": {
+			leases: []coordinationv1.Lease{
+				lease("replica-2", time.Second, 15),
+				lease("replica-0", time.Second, 15),
+				lease("replica-1", time.Second, 15),
+			},
+			want: []string{"replica-0", "replica-1", "replica-2"},
+		},
+		"Expired": {
+			leases: []coordinationv1.Lease{
+				lease("replica-0", time.Second, 15),
+				lease("replica-1", 20*time.Second, 15),
+			},
+			want: []string{"replica-0"},
+		},
+		"Incomplete": {
+			leases: []coordinationv1.Lease{
+				lease("replica-0", time.Second, 15),
+				{Spec: coordinationv1.LeaseSpec{}},
+			},
+			want: []string{"replica-0"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, liveMembers(tc.leases, now)); diff != "" {
+				t.Errorf("liveMembers(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
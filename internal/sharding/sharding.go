@@ -0,0 +1,65 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits the organizations and deployments over multiple
+// manager replicas by hashing the organization name.
+package sharding
+
+import (
+	"hash/fnv"
+
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// LabelShard is set on organizations and deployments to the member that
+	// reconciles them.
+	LabelShard = orgv1alpha1.Group + "/shard"
+	// LabelShardMember is set on the leases of the shard ring members.
+	LabelShardMember = orgv1alpha1.Group + "/shard-member"
+)
+
+// Owner returns the member that owns the organization, or an empty string if
+// there are no members. Members are ranked by rendezvous hashing, a change in
+// membership only moves the organizations of the members that joined or left.
+func Owner(members []string, organization string) string {
+	var owner string
+	var max uint64
+	for _, member := range members {
+		h := fnv.New64a()
+		h.Write([]byte(member))       // nolint:errcheck
+		h.Write([]byte{0})            // nolint:errcheck
+		h.Write([]byte(organization)) // nolint:errcheck
+		if sum := h.Sum64(); owner == "" || sum > max || (sum == max && member < owner) {
+			owner = member
+			max = sum
+		}
+	}
+	return owner
+}
+
+// Selector adds the shard of the member to the supplied selector, the cache
+// of a replica only holds the organizations and deployments of its shard.
+func Selector(member string, selector labels.Selector) labels.Selector {
+	r, err := labels.NewRequirement(LabelShard, selection.Equals, []string{member})
+	if err != nil {
+		// member is not a valid label value, nothing would match
+		return labels.Nothing()
+	}
+	return selector.Add(*r)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"testing"
+)
+
+func TestOwner(t *testing.T) {
+	members := []string{"replica-0", "replica-1", "replica-2"}
+	owner := Owner(members, "nokia")
+
+	var others []string
+	for _, m := range members {
+		if m != owner {
+			others = append(others, m)
+		}
+	}
+
+	cases := map[string]struct {
+		members []string
+		want    string
+	}{
+		"NoMembers": {
+			members: nil,
+			want:    "",
+		},
+		"SingleMember": {
+			members: []string{"replica-1"},
+			want:    "replica-1",
+		},
+		"OrderIndependent": {
+			members: []string{members[2], members[0], members[1]},
+			want:    owner,
+		},
+		"OtherMemberLeaves": {
+			members: []string{owner, others[0]},
+			want:    owner,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := Owner(tc.members, "nokia"); got != tc.want {
+				t.Errorf("Owner(%v, nokia): got %q, want %q", tc.members, got, tc.want)
+			}
+		})
+	}
+}
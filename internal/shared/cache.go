@@ -0,0 +1,93 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+// NewCache returns a cache constructor for the supplied namespaces, all
+// namespaces when empty. Objects with a selector are cached when they match
+// it.
+func NewCache(namespaces []string, selectors cache.SelectorsByObject) cache.NewCacheFunc {
+	namespaces = unique(namespaces)
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if len(selectors) != 0 {
+			opts.SelectorsByObject = selectors
+		}
+		switch len(namespaces) {
+		case 0:
+			return cache.New(config, opts)
+		case 1:
+			opts.Namespace = namespaces[0]
+			return cache.New(config, opts)
+		}
+		return cache.MultiNamespacedCacheBuilder(namespaces)(config, opts)
+	}
+}
+
+// NewOrganizationCache returns a cache constructor for the supplied
+// namespaces that only caches the organizations and deployments matching the
+// selector.
+func NewOrganizationCache(namespaces []string, selector labels.Selector) cache.NewCacheFunc {
+	var selectors cache.SelectorsByObject
+	if !selector.Empty() {
+		selectors = cache.SelectorsByObject{
+			&orgv1alpha1.Organization{}: {Label: selector},
+			&orgv1alpha1.Deployment{}:   {Label: selector},
+		}
+	}
+	return NewCache(namespaces, selectors)
+}
+
+// NewCluster returns a cluster with its own cache that shares the scheme and
+// rest mapper of the manager. The cluster is started by the manager.
+func NewCluster(mgr ctrl.Manager, newCache cache.NewCacheFunc) (cluster.Cluster, error) {
+	c, err := cluster.New(mgr.GetConfig(), func(o *cluster.Options) {
+		o.Scheme = mgr.GetScheme()
+		o.MapperProvider = func(*rest.Config) (meta.RESTMapper, error) { return mgr.GetRESTMapper(), nil }
+		o.NewCache = newCache
+	})
+	if err != nil {
+		return nil, err
+	}
+	// a cluster runs with the caches of the manager, before the controllers
+	if err := mgr.Add(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// unique returns the namespaces without duplicates and empty namespaces.
+func unique(namespaces []string) []string {
+	seen := make(map[string]bool, len(namespaces))
+	result := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		result = append(result, ns)
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnique(t *testing.T) {
+	cases := map[string]struct {
+		namespaces []string
+		want       []string
+	}{
+		"None": {
+			want: []string{},
+		},
+		"DuplicatesAndEmpty": {
+			namespaces: []string{"ndd-system", "", "default", "ndd-system", "apps"},
+			want:       []string{"ndd-system", "default", "apps"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, unique(tc.namespaces)); diff != "" {
+				t.Errorf("unique(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
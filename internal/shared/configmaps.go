@@ -0,0 +1,43 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+const (
+	// LabelManagedBy and ManagedBy label the ConfigMaps of the registry
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	ManagedBy      = "nddr-org-registry"
+)
+
+// NewConfigMapCache returns a cache of the ConfigMaps of the registry in the
+// supplied namespaces, all namespaces when empty. ConfigMaps without the
+// managed-by label are not cached. The cache is started by the manager.
+func NewConfigMapCache(mgr ctrl.Manager, namespaces []string) (cache.Cache, error) {
+	c, err := NewCluster(mgr, NewCache(namespaces, cache.SelectorsByObject{
+		&corev1.ConfigMap{}: {Label: labels.SelectorFromSet(labels.Set{LabelManagedBy: ManagedBy})},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return c.GetCache(), nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/event"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// WarningWindow is the window within which the controllers record a
+// repeated warning once.
+const WarningWindow = 10 * time.Minute
+
+// RepeatedWarningFilter returns a recorder that drops a warning event when
+// the same warning was recorded for the object within the window. The
+// managed reconciler records a warning on every failing reconcile, a
+// failure that persists across requeues is only recorded once per window.
+func RepeatedWarningFilter(r event.Recorder, window time.Duration) event.Recorder {
+	return &warningFilter{
+		Recorder: r,
+		warnings: &warnings{
+			window: window,
+			now:    time.Now,
+			seen:   make(map[warning]time.Time),
+		},
+	}
+}
+
+type warningFilter struct {
+	event.Recorder
+	warnings *warnings
+}
+
+func (f *warningFilter) Event(obj runtime.Object, e event.Event) {
+	if e.Type == event.TypeWarning && f.warnings.repeated(obj, e) {
+		return
+	}
+	f.Recorder.Event(obj, e)
+}
+
+func (f *warningFilter) WithAnnotations(keysAndValues ...string) event.Recorder {
+	return &warningFilter{
+		Recorder: f.Recorder.WithAnnotations(keysAndValues...),
+		warnings: f.warnings,
+	}
+}
+
+type warning struct {
+	object  string
+	reason  event.Reason
+	message string
+}
+
+// warnings are the recently recorded warnings, shared by the recorders
+// returned by WithAnnotations.
+type warnings struct {
+	window time.Duration
+	now    func() time.Time
+
+	m    sync.Mutex
+	seen map[warning]time.Time
+}
+
+// repeated returns true if the warning was recorded for the object within
+// the window, otherwise it remembers the warning.
+func (w *warnings) repeated(obj runtime.Object, e event.Event) bool {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	key := warning{
+		object:  string(o.GetUID()) + "/" + o.GetNamespace() + "/" + o.GetName(),
+		reason:  e.Reason,
+		message: e.Message,
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+	now := w.now()
+	for k, recorded := range w.seen {
+		if now.Sub(recorded) >= w.window {
+			delete(w.seen, k)
+		}
+	}
+	if _, ok := w.seen[key]; ok {
+		return true
+	}
+	w.seen[key] = now
+	return false
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/event"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// countingRecorder counts the recorded events by reason.
+type countingRecorder struct {
+	events map[event.Reason]int
+}
+
+func (r *countingRecorder) Event(obj runtime.Object, e event.Event) {
+	r.events[e.Reason]++
+}
+
+func (r *countingRecorder) WithAnnotations(keysAndValues ...string) event.Recorder {
+	return r
+}
+
+func TestRepeatedWarningFilter(t *testing.T) {
+	a := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a", UID: "a"}}
+	b := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b", UID: "b"}}
+	boom := event.Warning("Failed", errors.New("boom"))
+	bang := event.Warning("Failed", errors.New("bang"))
+	normal := event.Normal("Changed", "changed")
+
+	type record struct {
+		obj   runtime.Object
+		e     event.Event
+		after time.Duration
+	}
+	cases := map[string]struct {
+		records []record
+		want    map[event.Reason]int
+	}{
+		"RepeatedWarning": {
+			records: []record{{obj: a, e: boom}, {obj: a, e: boom}, {obj: a, e: boom, after: time.Minute}},
+			want:    map[event.Reason]int{"Failed": 1},
+		},
+		"DifferentMessage": {
+			records: []record{{obj: a, e: boom}, {obj: a, e: bang}},
+			want:    map[event.Reason]int{"Failed": 2},
+		},
+		"DifferentObject": {
+			records: []record{{obj: a, e: boom}, {obj: b, e: boom}},
+			want:    map[event.Reason]int{"Failed": 2},
+		},
+		"WindowExpired": {
+			records: []record{{obj: a, e: boom}, {obj: a, e: boom, after: 10 * time.Minute}},
+			want:    map[event.Reason]int{"Failed": 2},
+		},
+		"Normal": {
+			records: []record{{obj: a, e: normal}, {obj: a, e: normal}},
+			want:    map[event.Reason]int{"Changed": 2},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := &countingRecorder{events: map[event.Reason]int{}}
+			now := time.Now()
+			f := RepeatedWarningFilter(rec, 10*time.Minute).(*warningFilter)
+			f.warnings.now = func() time.Time { return now }
+
+			for _, r := range tc.records {
+				now = now.Add(r.after)
+				// the managed reconciler annotates the recorder per reconcile
+				f.WithAnnotations("name", "a").Event(r.obj, r.e)
+			}
+			for reason, want := range tc.want {
+				if got := rec.events[reason]; got != want {
+					t.Errorf("%s events: got %d, want %d", reason, got, want)
+				}
+			}
+		})
+	}
+}
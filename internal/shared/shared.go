@@ -20,6 +20,8 @@ import (
 
 	"github.com/yndd/ndd-runtime/pkg/logging"
 	"github.com/yndd/nddr-org-registry/internal/handler"
+	"github.com/yndd/nddr-org-registry/internal/readiness"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 )
 
 type NddControllerOptions struct {
@@ -27,4 +29,17 @@ type NddControllerOptions struct {
 	Poll      time.Duration
 	Namespace string
 	Handler   handler.Handler
+	Readiness *readiness.Reconciles
+	// AllowCrossNamespaceOrganizations lets a deployment use an organization
+	// of another namespace, by default both live in the same namespace
+	AllowCrossNamespaceOrganizations bool
+	// WatchNamespaces are the namespaces the manager watches, all namespaces
+	// when empty
+	WatchNamespaces []string
+	// PublicationNamespaces are the namespaces organizations publish
+	// ConfigMaps to besides the watched namespaces
+	PublicationNamespaces []string
+	// ConfigMaps caches the ConfigMaps of the registry, it is created by the
+	// setup of the controllers when nil
+	ConfigMaps cache.Cache
 }
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UnchangedStatusSkipper returns a manager whose client skips status updates
+// that do not change the status of the object. The managed reconciler
+// updates the status on every reconcile, every write bumps the
+// resourceVersion and wakes up all watchers.
+func UnchangedStatusSkipper(mgr ctrl.Manager) ctrl.Manager {
+	return &statusSkippingManager{
+		Manager: mgr,
+		client:  &statusSkippingClient{Client: mgr.GetClient()},
+	}
+}
+
+type statusSkippingManager struct {
+	ctrl.Manager
+	client client.Client
+}
+
+func (m *statusSkippingManager) GetClient() client.Client {
+	return m.client
+}
+
+type statusSkippingClient struct {
+	client.Client
+}
+
+func (c *statusSkippingClient) Status() client.StatusWriter {
+	return &statusSkippingWriter{
+		StatusWriter: c.Client.Status(),
+		reader:       c.Client,
+	}
+}
+
+type statusSkippingWriter struct {
+	client.StatusWriter
+	reader client.Reader
+}
+
+func (w *statusSkippingWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if len(opts) == 0 && statusUnchanged(ctx, w.reader, obj) {
+		return nil
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+// statusUnchanged returns true when the status of obj is semantically equal
+// to the status of the object the reader holds. The object is written when
+// either cannot be determined.
+func statusUnchanged(ctx context.Context, r client.Reader, obj client.Object) bool {
+	current, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return false
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		return false
+	}
+	if current.GetResourceVersion() != obj.GetResourceVersion() {
+		// the status is based on an outdated object, let the write conflict
+		return false
+	}
+	desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false
+	}
+	live, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return false
+	}
+	return equality.Semantic.DeepEqual(desired["status"], live["status"])
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"context"
+	"testing"
+
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newDeployment(t *testing.T, register map[string]string) *orgv1alpha1.Deployment {
+	t.Helper()
+	cr := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"}}
+	if err := cr.InitializeResource(); err != nil {
+		t.Fatalf("InitializeResource(): %v", err)
+	}
+	cr.SetStatus("up")
+	cr.SetStateRegister(register)
+	return cr
+}
+
+func TestStatusUpdateSkipsUnchanged(t *testing.T) {
+	register := map[string]string{"as": "nokia-as", "ipam": "nokia-ipam", "vlan": "nokia-vlan"}
+
+	cases := map[string]struct {
+		mutate    func(cr *orgv1alpha1.Deployment)
+		wantWrite bool
+	}{
+		"SameRegister": {
+			mutate:    func(cr *orgv1alpha1.Deployment) { cr.SetStateRegister(register) },
+			wantWrite: false,
+		},
+		"ChangedRegister": {
+			mutate: func(cr *orgv1alpha1.Deployment) {
+				cr.SetStateRegister(map[string]string{"as": "nokia-as"})
+			},
+			wantWrite: true,
+		},
+		"ChangedState": {
+			mutate:    func(cr *orgv1alpha1.Deployment) { cr.SetStatus("down") },
+			wantWrite: true,
+		},
+	}
+
+	s := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme(): %v", err)
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := &statusSkippingClient{Client: fake.NewClientBuilder().
+				WithScheme(s).
+				WithObjects(newDeployment(t, register)).
+				Build()}
+
+			cr := &orgv1alpha1.Deployment{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "nokia.dc1"}, cr); err != nil {
+				t.Fatalf("Get(): %v", err)
+			}
+			before := cr.GetResourceVersion()
+			tc.mutate(cr)
+			if err := c.Status().Update(ctx, cr); err != nil {
+				t.Fatalf("Status().Update(): %v", err)
+			}
+
+			got := &orgv1alpha1.Deployment{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(cr), got); err != nil {
+				t.Fatalf("Get(): %v", err)
+			}
+			if written := got.GetResourceVersion() != before; written != tc.wantWrite {
+				t.Errorf("Status().Update(): want write %t, got %t", tc.wantWrite, written)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const instrumentationName = "github.com/yndd/nddr-org-registry/internal/controllers"
+
+// NewReconciler wraps r so that every reconcile runs in its own span. The
+// span context is passed on to the reconciler, registry lookups and grpc
+// calls made during the reconcile become children of the span.
+func NewReconciler(name string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &reconciler{
+		name:       name,
+		reconciler: r,
+		tracer:     otel.Tracer(instrumentationName),
+	}
+}
+
+type reconciler struct {
+	name       string
+	reconciler reconcile.Reconciler
+	tracer     trace.Tracer
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx, span := r.tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("controller", r.name),
+		attribute.String("namespace", req.Namespace),
+		attribute.String("name", req.Name),
+	))
+	defer span.End()
+
+	result, err := r.reconciler.Reconcile(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(
+		attribute.Bool("requeue", result.Requeue),
+		attribute.String("requeue_after", result.RequeueAfter.String()),
+	)
+	return result, err
+}
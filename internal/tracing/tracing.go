@@ -0,0 +1,88 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing configures the OpenTelemetry tracer provider of the org
+// registry.
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+const (
+	serviceName = "nddr-org-registry"
+
+	// errors
+	errCreateExporter = "cannot create otlp trace exporter"
+	errCreateResource = "cannot create trace resource"
+)
+
+// Options configure the OTLP trace exporter.
+type Options struct {
+	// Endpoint of the OTLP grpc collector, tracing is disabled when empty.
+	Endpoint string
+	// Insecure disables TLS towards the collector.
+	Insecure bool
+	// SampleRatio is the fraction of new traces that are sampled, the
+	// decision of a remote parent is honoured.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator. The global
+// provider stays the OpenTelemetry no-op provider when no endpoint is
+// configured. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, o Options) (func(context.Context) error, error) {
+	if o.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(o.Endpoint)}
+	if o.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateExporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateResource)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return tp.Shutdown, nil
+}
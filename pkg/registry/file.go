@@ -0,0 +1,492 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/idalloc"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// reloadDelay coalesces the burst of file events editors generate
+	reloadDelay = 100 * time.Millisecond
+	// organizationScope is the scope of the organization ids of the directory
+	organizationScope = "organizations"
+
+	// errors
+	errReadDirectory = "cannot read registry directory"
+	errReadFile      = "cannot read registry file"
+	errDecodeFile    = "cannot decode registry file"
+	errWatchDir      = "cannot watch registry directory"
+)
+
+// fileStore serves organizations and deployments from a directory of yaml
+// files. The state of the resources is resolved locally with the code the
+// organization and deployment controllers use, from the deployment classes,
+// deployment kind definitions and register policies of the directory.
+type fileStore struct {
+	log logging.Logger
+	dir string
+
+	m    sync.RWMutex
+	orgs map[types.NamespacedName]*orgv1alpha1.Organization
+	deps map[types.NamespacedName]*orgv1alpha1.Deployment
+	err  error
+	// version is increased on every load and used as resourceVersion
+	version   uint64
+	onChanges []func()
+}
+
+// newFileStore loads the directory and reloads it on change until ctx is
+// done.
+func newFileStore(ctx context.Context, log logging.Logger, dir string) *fileStore {
+	s := &fileStore{
+		log:  log.WithValues("directory", dir),
+		dir:  dir,
+		orgs: make(map[types.NamespacedName]*orgv1alpha1.Organization),
+		deps: make(map[types.NamespacedName]*orgv1alpha1.Deployment),
+	}
+	s.reload()
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.setErr(errors.Wrap(err, errWatchDir))
+		return s
+	}
+	if err := w.Add(dir); err != nil {
+		w.Close()
+		s.setErr(errors.Wrap(err, errWatchDir))
+		return s
+	}
+	go s.watch(ctx, w)
+	return s
+}
+
+func (s *fileStore) getOrganization(ctx context.Context, namespace, name string) (orgv1alpha1.Org, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	org, ok := s.orgs[namespacedName(namespace, name)]
+	if !ok {
+		return nil, kerrors.NewNotFound(orgv1alpha1.GroupVersion.WithResource("organizations").GroupResource(), name)
+	}
+	return org.DeepCopy(), nil
+}
+
+func (s *fileStore) getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	dep, ok := s.deps[namespacedName(namespace, name)]
+	if !ok {
+		return nil, kerrors.NewNotFound(orgv1alpha1.GroupVersion.WithResource("deployments").GroupResource(), name)
+	}
+	return dep.DeepCopy(), nil
+}
+
+func (s *fileStore) listDeployments(ctx context.Context, namespace string) ([]orgv1alpha1.Dp, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	deps := make([]orgv1alpha1.Dp, 0, len(s.deps))
+	for nn, dep := range s.deps {
+		if nn.Namespace == namespace {
+			deps = append(deps, dep.DeepCopy())
+		}
+	}
+	return deps, nil
+}
+
+// subscribe registers a function that is called after every reload.
+func (s *fileStore) subscribe(fn func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.onChanges = append(s.onChanges, fn)
+}
+
+func (s *fileStore) setErr(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.err = err
+}
+
+// watch reloads the directory once its files did not change for the reload
+// delay. The reloads run on the watch goroutine, one at a time, so an older
+// load never replaces a newer one.
+func (s *fileStore) watch(ctx context.Context, w *fsnotify.Watcher) {
+	defer w.Close()
+	var timer *time.Timer
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-reload:
+			timer, reload = nil, nil
+			s.reload()
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if !isRegistryFile(ev.Name) {
+				continue
+			}
+			s.log.Debug("registry file changed", "file", ev.Name, "op", ev.Op.String())
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(reloadDelay)
+			reload = timer.C
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			s.log.Debug("registry watch error", "error", err)
+		}
+	}
+}
+
+// reload reads all files in the directory and replaces the served resources.
+// When a file cannot be read or decoded the previous resources are kept.
+func (s *fileStore) reload() {
+	objs, err := s.load()
+	if err != nil {
+		s.log.Debug("cannot load registry directory", "error", err)
+		s.m.Lock()
+		// only surface the error if nothing was ever loaded
+		if len(s.orgs) == 0 && len(s.deps) == 0 {
+			s.err = err
+		}
+		s.m.Unlock()
+		return
+	}
+
+	s.m.Lock()
+	// the ids of the previous load are kept
+	for nn, org := range objs.orgs {
+		if prev, ok := s.orgs[nn]; ok && org.GetStateOrganizationID() == 0 {
+			org.SetStateOrganizationID(prev.GetStateOrganizationID())
+		}
+	}
+	for nn, dep := range objs.deps {
+		if prev, ok := s.deps[nn]; ok && dep.GetStateDeploymentID() == 0 {
+			dep.SetStateDeploymentID(prev.GetStateDeploymentID())
+		}
+	}
+	objs.resolve(s.log)
+
+	s.version++
+	version := strconv.FormatUint(s.version, 10)
+	for _, org := range objs.orgs {
+		org.SetResourceVersion(version)
+	}
+	for _, dep := range objs.deps {
+		dep.SetResourceVersion(version)
+	}
+	s.orgs = objs.orgs
+	s.deps = objs.deps
+	s.err = nil
+	onChanges := s.onChanges
+	s.m.Unlock()
+
+	s.log.Debug("registry directory loaded", "organizations", len(objs.orgs), "deployments", len(objs.deps))
+	for _, fn := range onChanges {
+		fn()
+	}
+}
+
+func (s *fileStore) load() (*fileObjects, error) {
+	objs := newFileObjects()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, errReadDirectory)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isRegistryFile(entry.Name()) {
+			continue
+		}
+		if err := objs.decodeFile(filepath.Join(s.dir, entry.Name())); err != nil {
+			return nil, err
+		}
+	}
+	return objs, nil
+}
+
+// fileObjects are the objects of the registry directory, the deployments are
+// resolved from them like the deployment controller resolves them from
+// kubernetes.
+type fileObjects struct {
+	orgs     map[types.NamespacedName]*orgv1alpha1.Organization
+	deps     map[types.NamespacedName]*orgv1alpha1.Deployment
+	classes  map[types.NamespacedName]*orgv1alpha1.DeploymentClass
+	policies map[types.NamespacedName]*orgv1alpha1.RegisterPolicy
+	kinds    map[string]*orgv1alpha1.DeploymentKindDefinition
+
+	ids *idalloc.Allocator
+}
+
+func newFileObjects() *fileObjects {
+	return &fileObjects{
+		orgs:     make(map[types.NamespacedName]*orgv1alpha1.Organization),
+		deps:     make(map[types.NamespacedName]*orgv1alpha1.Deployment),
+		classes:  make(map[types.NamespacedName]*orgv1alpha1.DeploymentClass),
+		policies: make(map[types.NamespacedName]*orgv1alpha1.RegisterPolicy),
+		kinds:    make(map[string]*orgv1alpha1.DeploymentKindDefinition),
+		ids:      idalloc.New(MaxDeploymentID),
+	}
+}
+
+func (o *fileObjects) decodeFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, errReadFile)
+	}
+	r := yamlutil.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		doc, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s: %s", errDecodeFile, path)
+		}
+		if err := o.decode(doc); err != nil {
+			return errors.Wrapf(err, "%s: %s", errDecodeFile, path)
+		}
+	}
+}
+
+// decode adds the object of the yaml document, documents of other groups
+// are ignored.
+func (o *fileObjects) decode(doc []byte) error {
+	tm := &metav1.TypeMeta{}
+	if err := yaml.Unmarshal(doc, tm); err != nil {
+		return err
+	}
+	if tm.GroupVersionKind().GroupVersion() != orgv1alpha1.GroupVersion {
+		return nil
+	}
+	switch tm.Kind {
+	case orgv1alpha1.OrganizationKindKind:
+		org := &orgv1alpha1.Organization{}
+		if err := yaml.Unmarshal(doc, org); err != nil {
+			return err
+		}
+		if err := org.InitializeResource(); err != nil {
+			return err
+		}
+		o.orgs[setDefaultNamespace(org)] = org
+	case orgv1alpha1.DeploymentKindKind:
+		dep := &orgv1alpha1.Deployment{}
+		if err := yaml.Unmarshal(doc, dep); err != nil {
+			return err
+		}
+		if err := dep.InitializeResource(); err != nil {
+			return err
+		}
+		o.deps[setDefaultNamespace(dep)] = dep
+	case orgv1alpha1.DeploymentClassKindKind:
+		class := &orgv1alpha1.DeploymentClass{}
+		if err := yaml.Unmarshal(doc, class); err != nil {
+			return err
+		}
+		o.classes[setDefaultNamespace(class)] = class
+	case orgv1alpha1.RegisterPolicyKindKind:
+		policy := &orgv1alpha1.RegisterPolicy{}
+		if err := yaml.Unmarshal(doc, policy); err != nil {
+			return err
+		}
+		o.policies[setDefaultNamespace(policy)] = policy
+	case orgv1alpha1.DeploymentKindDefinitionKindKind:
+		kind := &orgv1alpha1.DeploymentKindDefinition{}
+		if err := yaml.Unmarshal(doc, kind); err != nil {
+			return err
+		}
+		o.kinds[kind.GetName()] = kind
+	}
+	return nil
+}
+
+// resolve sets the state of the organizations and deployments. The objects
+// are resolved in name order, so the allocated ids do not depend on the
+// order of the files.
+func (o *fileObjects) resolve(log logging.Logger) {
+	orgIDs := idalloc.New(MaxOrganizationID)
+	orgNames := make([]types.NamespacedName, 0, len(o.orgs))
+	orgClaims := make([]idalloc.Claim, 0, len(o.orgs))
+	for nn, org := range o.orgs {
+		orgNames = append(orgNames, nn)
+		orgClaims = append(orgClaims, fileClaim(org, org.GetStateOrganizationID()))
+	}
+	for _, nn := range sortNames(orgNames) {
+		org := o.orgs[nn]
+		id, err := orgIDs.Allocate(organizationScope, fileClaim(org, org.GetStateOrganizationID()), orgClaims)
+		if err != nil {
+			log.Debug("cannot allocate organization id", "name", nn.String(), "error", err)
+		}
+		if err := ResolveOrganization(org, id); err != nil {
+			log.Debug("invalid organization register", "name", nn.String(), "error", err)
+		}
+	}
+
+	depNames := make([]types.NamespacedName, 0, len(o.deps))
+	for nn := range o.deps {
+		depNames = append(depNames, nn)
+	}
+	ctx := context.Background()
+	for _, nn := range sortNames(depNames) {
+		dep := o.deps[nn]
+		if _, err := ResolveDeployment(ctx, log, o, dep); err != nil {
+			log.Debug("cannot resolve deployment", "name", nn.String(), "error", err)
+		}
+	}
+}
+
+// AllowCrossNamespaceOrganizations returns true, the directory is not split
+// in tenants.
+func (o *fileObjects) AllowCrossNamespaceOrganizations() bool {
+	return true
+}
+
+func (o *fileObjects) GetDeploymentClass(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dc, error) {
+	name := cr.GetDeploymentClassName()
+	if name == "" {
+		return nil, nil
+	}
+	class, ok := o.classes[types.NamespacedName{Namespace: cr.GetNamespace(), Name: name}]
+	if !ok {
+		return nil, kerrors.NewNotFound(orgv1alpha1.GroupVersion.WithResource("deploymentclasses").GroupResource(), name)
+	}
+	return class, nil
+}
+
+func (o *fileObjects) GetDeploymentKind(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dkd, error) {
+	name := cr.GetKind()
+	if name == "" {
+		return nil, nil
+	}
+	kind, ok := o.kinds[name]
+	switch {
+	case ok:
+		return kind, nil
+	case orgv1alpha1.IsBuiltinDeploymentKind(name):
+		return nil, nil
+	}
+	return nil, kerrors.NewNotFound(orgv1alpha1.GroupVersion.WithResource("deploymentkinddefinitions").GroupResource(), name)
+}
+
+func (o *fileObjects) GetOrganization(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Org, error) {
+	namespace := cr.GetNamespace()
+	if ns := cr.GetOrganizationNamespace(); ns != "" {
+		namespace = ns
+	}
+	for nn, org := range o.orgs {
+		if nn.Namespace == namespace && org.GetOrganizationName() == cr.GetOrganizationName() {
+			return org, nil
+		}
+	}
+	return nil, nil
+}
+
+func (o *fileObjects) ListRegisterPolicies(ctx context.Context, org orgv1alpha1.Org) ([]orgv1alpha1.Rp, error) {
+	var policies []orgv1alpha1.Rp
+	for nn, policy := range o.policies {
+		if nn.Namespace == org.GetNamespace() {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+func (o *fileObjects) AllocateDeploymentID(ctx context.Context, org orgv1alpha1.Org, cr orgv1alpha1.Dp) (int64, error) {
+	var claims []idalloc.Claim
+	for _, dep := range o.deps {
+		if other, _ := o.GetOrganization(ctx, dep); other == org {
+			claims = append(claims, fileClaim(dep, dep.GetStateDeploymentID()))
+		}
+	}
+	return o.ids.Allocate(string(fileUID(org)), fileClaim(cr, cr.GetStateDeploymentID()), claims)
+}
+
+// fileClaim returns the claim of an object of the directory, the objects
+// have no uid so their name identifies them.
+func fileClaim(obj metav1.Object, id int64) idalloc.Claim {
+	return idalloc.Claim{UID: fileUID(obj), ID: id, Created: obj.GetCreationTimestamp()}
+}
+
+func fileUID(obj metav1.Object) types.UID {
+	if uid := obj.GetUID(); uid != "" {
+		return uid
+	}
+	return types.UID(obj.GetNamespace() + "/" + obj.GetName())
+}
+
+// setDefaultNamespace sets the default namespace on an object without one
+// and returns its name.
+func setDefaultNamespace(obj metav1.Object) types.NamespacedName {
+	nn := namespacedName(obj.GetNamespace(), obj.GetName())
+	obj.SetNamespace(nn.Namespace)
+	return nn
+}
+
+func sortNames(names []types.NamespacedName) []types.NamespacedName {
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+	return names
+}
+
+func namespacedName(namespace, name string) types.NamespacedName {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}
+}
+
+func isRegistryFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+)
+
+// examples are the example files the file store is tested with.
+var examples = []string{"nokia.yaml", "region1.yaml", "class-standard-dc.yaml", "kind-edge.yaml", "policy-regions.yaml"}
+
+// writeDir returns a directory with the examples and the supplied files.
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range examples {
+		b, err := os.ReadFile(filepath.Join("..", "..", "examples", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFileStoreResolveDeployment(t *testing.T) {
+	dir := writeDir(t, map[string]string{"broken.yaml": `
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: nokia.noclass
+  namespace: default
+spec:
+  properties:
+    deployment-class-name: unknown
+---
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: nokia.nokind
+spec:
+  properties:
+    kind: unknown
+---
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: nokia.edge2
+spec:
+  properties:
+    kind: edge
+    attributes:
+      uplinks: 9
+---
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: nokia.disabled
+spec:
+  properties:
+    kind: dc
+    admin-state: disable
+---
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: acme.dc1
+spec:
+  properties:
+    kind: dc
+`})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := newFileStore(ctx, logging.NewNopLogger(), dir)
+
+	orgRegister := map[string]string{"ipam": "nokia-default", "ni": "nokia-default", "as": "nokia-default", "vlan": "nokia-default"}
+	cases := map[string]struct {
+		name         string
+		wantStatus   string
+		wantReason   string
+		wantRegister map[string]string
+		wantPolicy   *orgv1alpha1.RegisterPolicyRuleReference
+		wantDerived  map[string]string
+	}{
+		"Organization": {
+			name:         "nokia.region1",
+			wantStatus:   "up",
+			wantRegister: orgRegister,
+			// the deployments of nokia are numbered in name order, except
+			// the ones that are down before their id is allocated
+			wantDerived: map[string]string{"rt-base": "65000:3", "hostname-prefix": "nokia-region1"},
+		},
+		"ClassAndPolicy": {
+			name:       "nokia.region2",
+			wantStatus: "up",
+			wantRegister: map[string]string{
+				"ipam": "nokia-core", "ni": "nokia-default", "as": "nokia-default", "vlan": "nokia-dc-vlan",
+			},
+			wantPolicy:  &orgv1alpha1.RegisterPolicyRuleReference{Policy: "regions", Rule: "core"},
+			wantDerived: map[string]string{"rt-base": "65000:4", "hostname-prefix": "nokia-region2"},
+		},
+		"KindDefinition": {
+			name:       "nokia.edge1",
+			wantStatus: "up",
+			wantRegister: map[string]string{
+				"ipam": "nokia-default", "ni": "nokia-default", "as": "nokia-default", "vlan": "nokia-default", "esi": "nokia-edge-esi",
+			},
+			wantDerived: map[string]string{"rt-base": "65000:2", "hostname-prefix": "nokia-edge1"},
+		},
+		"ClassNotFound": {
+			name:         "nokia.noclass",
+			wantStatus:   "down",
+			wantReason:   StatusReasonDeploymentClassNotFound,
+			wantRegister: map[string]string{},
+		},
+		"KindNotDefined": {
+			name:         "nokia.nokind",
+			wantStatus:   "down",
+			wantReason:   StatusReasonDeploymentKindNotDefined,
+			wantRegister: map[string]string{},
+		},
+		"AttributesInvalid": {
+			name:         "nokia.edge2",
+			wantStatus:   "down",
+			wantReason:   StatusReasonAttributesInvalid,
+			wantRegister: map[string]string{},
+		},
+		"AdminStateDisabled": {
+			name:         "nokia.disabled",
+			wantStatus:   "down",
+			wantReason:   StatusReasonAdminStateDisabled,
+			wantRegister: map[string]string{},
+		},
+		"OrganizationNotFound": {
+			name:         "acme.dc1",
+			wantStatus:   "down",
+			wantReason:   StatusReasonOrganizationNotFound,
+			wantRegister: map[string]string{},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dep, err := s.getDeployment(ctx, "default", tc.name)
+			if err != nil {
+				t.Fatalf("getDeployment(...): unexpected error: %v", err)
+			}
+			if dep.GetStatus() != tc.wantStatus || dep.GetReason() != tc.wantReason {
+				t.Errorf("getDeployment(...): want status %q reason %q, got %q %q", tc.wantStatus, tc.wantReason, dep.GetStatus(), dep.GetReason())
+			}
+			if !reflect.DeepEqual(dep.GetStateRegister(), tc.wantRegister) {
+				t.Errorf("getDeployment(...): want register %v, got %v", tc.wantRegister, dep.GetStateRegister())
+			}
+			if !reflect.DeepEqual(dep.GetStateRegisterPolicy(), tc.wantPolicy) {
+				t.Errorf("getDeployment(...): want register policy %v, got %v", tc.wantPolicy, dep.GetStateRegisterPolicy())
+			}
+			if !reflect.DeepEqual(dep.GetStateDerived(), tc.wantDerived) {
+				t.Errorf("getDeployment(...): want derived %v, got %v", tc.wantDerived, dep.GetStateDerived())
+			}
+		})
+	}
+}
+
+func TestFileStoreKeepsIDs(t *testing.T) {
+	dir := writeDir(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := New(WithDirectory(ctx, dir))
+
+	want := map[string]IDs{
+		"nokia":         {OrganizationID: 1},
+		"nokia.edge1":   {OrganizationID: 1, DeploymentID: 1},
+		"nokia.region1": {OrganizationID: 1, DeploymentID: 2},
+		"nokia.region2": {OrganizationID: 1, DeploymentID: 3},
+	}
+	check := func(want map[string]IDs) {
+		t.Helper()
+		for odaName, ids := range want {
+			got, err := r.GetIDsByName(ctx, "default", odaName)
+			if err != nil {
+				t.Fatalf("GetIDsByName(%s): unexpected error: %v", odaName, err)
+			}
+			if got != ids {
+				t.Errorf("GetIDsByName(%s): want %+v, got %+v", odaName, ids, got)
+			}
+		}
+	}
+	check(want)
+
+	// objects that sort first do not take the ids of the loaded ones
+	if err := os.WriteFile(filepath.Join(dir, "acme.yaml"), []byte(`
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Organization
+metadata:
+  name: acme
+---
+apiVersion: org.nddr.yndd.io/v1alpha1
+kind: Deployment
+metadata:
+  name: nokia.a
+spec:
+  properties:
+    kind: dc
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the watch reloads the directory after the reload delay
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := r.GetIDsByName(ctx, "default", "acme"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the directory was not reloaded after acme.yaml was written")
+		}
+		time.Sleep(reloadDelay)
+	}
+
+	want["acme"] = IDs{OrganizationID: 2}
+	want["nokia.a"] = IDs{OrganizationID: 1, DeploymentID: 4}
+	check(want)
+}
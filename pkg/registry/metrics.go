@@ -0,0 +1,157 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "nddr_org_registry"
+
+	collectTimeout = 5 * time.Second
+)
+
+// Reasons of register resolution failures.
+const (
+	FailureReasonNoStore                 = "no_store"
+	FailureReasonNotFound                = "not_found"
+	FailureReasonMissingCriticalRegister = "missing_critical_register"
+	FailureReasonError                   = "error"
+)
+
+var (
+	// RegisterResolutionFailures counts the failed register and address
+	// allocation strategy lookups by reason.
+	RegisterResolutionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "register_resolution_failures_total",
+		Help:      "Number of failed register resolutions by reason.",
+	}, []string{"reason"})
+
+	// RegistryClientDiscoveryDuration observes how long the discovery of a
+	// registry endpoint takes in GetRegistryClient.
+	RegistryClientDiscoveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "registry_client_discovery_duration_seconds",
+		Help:      "Latency of the registry endpoint discovery in GetRegistryClient.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"register"})
+
+	// RegistryClientDialDuration observes how long GetRegistryClient takes to
+	// dial a registry.
+	RegistryClientDialDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "registry_client_dial_duration_seconds",
+		Help:      "Latency of dialing a registry in GetRegistryClient.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"register"})
+)
+
+func init() {
+	// consumers of the registry expose the metrics through the controller
+	// runtime metrics endpoint of their manager
+	metrics.Registry.MustRegister(
+		RegisterResolutionFailures,
+		RegistryClientDiscoveryDuration,
+		RegistryClientDialDuration,
+	)
+}
+
+// failureReason returns the resolution failure reason of an error.
+func failureReason(err error) string {
+	switch {
+	case kerrors.IsNotFound(err):
+		return FailureReasonNotFound
+	case IsCriticalRegisterError(err):
+		return FailureReasonMissingCriticalRegister
+	}
+	return FailureReasonError
+}
+
+// resolutionFailed records a failed register resolution and returns err.
+func resolutionFailed(err error) error {
+	RegisterResolutionFailures.WithLabelValues(failureReason(err)).Inc()
+	return err
+}
+
+var (
+	organizationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "organizations"),
+		"Number of organizations by status.",
+		[]string{"status"}, nil,
+	)
+	deploymentsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "deployments"),
+		"Number of deployments by kind, region, admin state and status.",
+		[]string{"kind", "region", "admin_state", "status"}, nil,
+	)
+)
+
+// NewCollector returns a prometheus collector that reports the number of
+// organizations and deployments read through the supplied reader, typically
+// the cache backed client of a manager, at scrape time.
+func NewCollector(r client.Reader) prometheus.Collector {
+	return &collector{reader: r}
+}
+
+type collector struct {
+	reader client.Reader
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- organizationsDesc
+	ch <- deploymentsDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	orgs := &orgv1alpha1.OrganizationList{}
+	if err := c.reader.List(ctx, orgs); err != nil {
+		ch <- prometheus.NewInvalidMetric(organizationsDesc, err)
+	} else {
+		counts := make(map[string]float64)
+		for _, org := range orgs.GetOrganizations() {
+			counts[org.GetStatus()]++
+		}
+		for status, n := range counts {
+			ch <- prometheus.MustNewConstMetric(organizationsDesc, prometheus.GaugeValue, n, status)
+		}
+	}
+
+	deps := &orgv1alpha1.DeploymentList{}
+	if err := c.reader.List(ctx, deps); err != nil {
+		ch <- prometheus.NewInvalidMetric(deploymentsDesc, err)
+	} else {
+		counts := make(map[[4]string]float64)
+		for _, dep := range deps.GetDeployments() {
+			counts[[4]string{dep.GetKind(), dep.GetRegion(), dep.GetAdminState(), dep.GetStatus()}]++
+		}
+		for l, n := range counts {
+			ch <- prometheus.MustNewConstMetric(deploymentsDesc, prometheus.GaugeValue, n, l[:]...)
+		}
+	}
+}
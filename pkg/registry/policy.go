@@ -0,0 +1,93 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+)
+
+const (
+	// errors
+	errInvalidPolicyRule = "register policy %s rule %s: %w"
+)
+
+// A PolicyMatch is the register policy rule that matched a deployment.
+type PolicyMatch struct {
+	Policy   string
+	Rule     string
+	Register map[string]string
+}
+
+// Reference returns the reference to the rule reported in the deployment
+// status.
+func (m *PolicyMatch) Reference() *orgv1alpha1.RegisterPolicyRuleReference {
+	if m == nil {
+		return nil
+	}
+	return &orgv1alpha1.RegisterPolicyRuleReference{Policy: m.Policy, Rule: m.Rule}
+}
+
+// MatchRegisterPolicy returns the first rule of the register policies of the
+// organization that matches the deployment, nil when no rule matches.
+// Policies are evaluated in name order and their rules in order. A rule with
+// an invalid label selector does not match, the error is returned alongside
+// the match.
+func MatchRegisterPolicy(policies []orgv1alpha1.Rp, org orgv1alpha1.Org, dep orgv1alpha1.Dp) (*PolicyMatch, error) {
+	sorted := make([]orgv1alpha1.Rp, 0, len(policies))
+	for _, p := range policies {
+		if p.GetNamespace() == org.GetNamespace() && p.GetOrganizationName() == org.GetOrganizationName() {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+
+	var invalid error
+	for _, p := range sorted {
+		for _, rule := range p.GetRules() {
+			if rule == nil {
+				continue
+			}
+			ok, err := rule.Matches(dep)
+			if err != nil {
+				if invalid == nil {
+					invalid = fmt.Errorf(errInvalidPolicyRule, p.GetName(), rule.Name, err)
+				}
+				continue
+			}
+			if ok {
+				return &PolicyMatch{Policy: p.GetName(), Rule: rule.Name, Register: rule.GetRegister()}, invalid
+			}
+		}
+	}
+	return nil, invalid
+}
+
+// PolicyRegister returns the register of the deployment with the register of
+// the matched rule layered between the organization and deployment register.
+func PolicyRegister(orgRegister map[string]string, m *PolicyMatch, depRegister map[string]string) map[string]string {
+	if m != nil {
+		policyRegister := make(map[string]string, len(m.Register))
+		for kind, name := range m.Register {
+			policyRegister[kind] = name
+		}
+		orgRegister = DeploymentRegister(orgRegister, policyRegister)
+	}
+	return DeploymentRegister(orgRegister, depRegister)
+}
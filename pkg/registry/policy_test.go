@@ -0,0 +1,124 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yndd/ndd-runtime/pkg/utils"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func policy(name, org string, rules ...*orgv1alpha1.RegisterPolicyRule) orgv1alpha1.Rp {
+	return &orgv1alpha1.RegisterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: orgv1alpha1.RegisterPolicySpec{Properties: orgv1alpha1.RegisterPolicyProperties{
+			Organization: org,
+			Rules:        rules,
+		}},
+	}
+}
+
+func rule(name string, match orgv1alpha1.RegisterPolicyMatch, ipam string) *orgv1alpha1.RegisterPolicyRule {
+	return &orgv1alpha1.RegisterPolicyRule{
+		Name:     name,
+		Match:    match,
+		Register: []*nddov1.Register{{Kind: utils.StringPtr("ipam"), Name: utils.StringPtr(ipam)}},
+	}
+}
+
+func TestMatchRegisterPolicy(t *testing.T) {
+	org := &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}}
+	dep := &orgv1alpha1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.wan1", Labels: map[string]string{"tier": "core"}},
+		Spec: orgv1alpha1.DeploymentSpec{Properties: orgv1alpha1.DeploymentProperties{
+			Kind:   utils.StringPtr("wan"),
+			Region: utils.StringPtr("emea"),
+		}},
+	}
+	wan := orgv1alpha1.RegisterPolicyMatch{Kind: utils.StringPtr("wan")}
+	emeaWan := orgv1alpha1.RegisterPolicyMatch{Kind: utils.StringPtr("wan"), Region: utils.StringPtr("emea")}
+	dc := orgv1alpha1.RegisterPolicyMatch{Kind: utils.StringPtr("dc")}
+	core := orgv1alpha1.RegisterPolicyMatch{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "core"}}}
+	invalid := orgv1alpha1.RegisterPolicyMatch{LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "bogus"}}}}
+
+	cases := map[string]struct {
+		policies []orgv1alpha1.Rp
+		want     *PolicyMatch
+		wantErr  bool
+	}{
+		"NoPolicies": {},
+		"NoMatch": {
+			policies: []orgv1alpha1.Rp{policy("a", "nokia", rule("dc", dc, "dc-ipam"))},
+		},
+		"FirstRuleWins": {
+			policies: []orgv1alpha1.Rp{policy("a", "nokia",
+				rule("dc", dc, "dc-ipam"),
+				rule("emea-wan", emeaWan, "emea-wan"),
+				rule("wan", wan, "wan"),
+			)},
+			want: &PolicyMatch{Policy: "a", Rule: "emea-wan", Register: map[string]string{"ipam": "emea-wan"}},
+		},
+		"PoliciesInNameOrder": {
+			policies: []orgv1alpha1.Rp{
+				policy("b", "nokia", rule("wan", wan, "b-wan")),
+				policy("a", "nokia", rule("core", core, "a-core")),
+			},
+			want: &PolicyMatch{Policy: "a", Rule: "core", Register: map[string]string{"ipam": "a-core"}},
+		},
+		"OtherOrganization": {
+			policies: []orgv1alpha1.Rp{policy("a", "acme", rule("wan", wan, "wan"))},
+		},
+		"InvalidRuleIsSkipped": {
+			policies: []orgv1alpha1.Rp{policy("a", "nokia",
+				rule("invalid", invalid, "invalid"),
+				rule("wan", wan, "wan"),
+			)},
+			want:    &PolicyMatch{Policy: "a", Rule: "wan", Register: map[string]string{"ipam": "wan"}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := MatchRegisterPolicy(tc.policies, org, dep)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("MatchRegisterPolicy(): want error %t, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("MatchRegisterPolicy(): want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicyRegister(t *testing.T) {
+	org := map[string]string{"ipam": "org-ipam", "as": "org-as", "vlan": "org-vlan"}
+	match := &PolicyMatch{Register: map[string]string{"ipam": "policy-ipam", "vlan": "policy-vlan"}}
+	dep := map[string]string{"vlan": "dep-vlan"}
+
+	want := map[string]string{"ipam": "policy-ipam", "as": "org-as", "vlan": "dep-vlan"}
+	if got := PolicyRegister(org, match, dep); !reflect.DeepEqual(got, want) {
+		t.Errorf("PolicyRegister(): want %v, got %v", want, got)
+	}
+	if match.Register["vlan"] != "policy-vlan" {
+		t.Errorf("PolicyRegister(): the register of the match was modified: %v", match.Register)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+)
+
+// CriticalRegisters returns the registers that must be present before a
+// register is handed out. ipam, as and ni are critical right now since they
+// serve dynamic grpc services.
+func CriticalRegisters() []string {
+	return []string{
+		RegisterKindIpam.String(),
+		RegisterKindAs.String(),
+		RegisterKindNi.String(),
+	}
+}
+
+// A CriticalRegisterError is returned when a critical register is missing.
+type CriticalRegisterError struct {
+	Register string
+}
+
+func (e *CriticalRegisterError) Error() string {
+	return fmt.Sprintf("critical register %s not found in registry", e.Register)
+}
+
+// IsCriticalRegisterError returns true if the error indicates a missing
+// critical register.
+func IsCriticalRegisterError(err error) bool {
+	var e *CriticalRegisterError
+	return errors.As(err, &e)
+}
+
+// ValidateRegister returns an error if one of the critical registers, or
+// one of the supplied additional critical registers of a deployment kind, is
+// missing from the supplied register.
+func ValidateRegister(registers map[string]string, critical ...string) error {
+	for _, register := range append(CriticalRegisters(), critical...) {
+		if _, ok := registers[register]; !ok {
+			return &CriticalRegisterError{Register: register}
+		}
+	}
+	return nil
+}
+
+// DeploymentRegister returns the effective register of a deployment. Entries
+// of the deployment register take precedence, the remaining kinds are
+// inherited from the organization register.
+func DeploymentRegister(orgRegister, depRegister map[string]string) map[string]string {
+	for orgKind, orgName := range orgRegister {
+		if _, ok := depRegister[orgKind]; !ok {
+			depRegister[orgKind] = orgName
+		}
+	}
+	return depRegister
+}
+
+// DeploymentAddressAllocationStrategy returns the effective address
+// allocation strategy of a deployment.
+func DeploymentAddressAllocationStrategy(orgass, depaas *nddov1.AddressAllocationStrategy) *nddov1.AddressAllocationStrategy {
+	if depaas != nil {
+		return depaas
+	}
+	return orgass
+}
+
+// ClassAddressAllocationStrategy returns the address allocation strategy of
+// a deployment, the strategy of its class applies when the deployment does
+// not set one.
+func ClassAddressAllocationStrategy(classaas, depaas *nddov1.AddressAllocationStrategy) *nddov1.AddressAllocationStrategy {
+	if classaas != nil && (depaas == nil || reflect.ValueOf(*depaas).IsZero()) {
+		return classaas
+	}
+	return depaas
+}
@@ -18,9 +18,12 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/yndd/app-runtime/pkg/odns"
 	pkgmetav1 "github.com/yndd/ndd-core/apis/pkg/meta/v1"
@@ -32,14 +35,24 @@ import (
 	"github.com/yndd/nddo-grpc/resource/resourcepb"
 	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
 	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	nddNamespace     = "ndd-system"
 	defaultNamespace = "default"
+
+	// errors
+	errNoStore        = "registry has no client or directory configured"
+	errNoReader       = "registry client discovery requires a kubernetes reader"
+	errInvalidOdaName = "invalid odns name %q, it has no organization"
+	errNoIDs          = "ids of %q are not allocated yet"
+	errNoDerived      = "%q has no derived identifiers, only deployments have"
 )
 
 type RegisterKind string
@@ -71,14 +84,38 @@ func (r RegisterKind) String() string {
 	return "unknown"
 }
 
+// store provides the organizations and deployments the registry resolves
+// registers from.
+type store interface {
+	getOrganization(ctx context.Context, namespace, name string) (orgv1alpha1.Org, error)
+	getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error)
+	listDeployments(ctx context.Context, namespace string) ([]orgv1alpha1.Dp, error)
+}
+
 type registry struct {
 	log logging.Logger
 	// kubernetes
 	client client.Client
+	// reader discovers the registry services
+	reader client.Reader
+
+	store store
+	// file backed store, set when the registry is used without kubernetes
+	files *fileStore
+
+	// informers provide the change notifications of watches
+	informers cache.Informers
+	watchers  watchers
+
+	m sync.Mutex
+	// clients are the registry clients per address
+	clients map[string]resourcepb.ResourceClient
 }
 
 func New(opts ...Option) Registry {
-	s := &registry{}
+	s := &registry{
+		log: logging.NewNopLogger(),
+	}
 
 	for _, opt := range opts {
 		opt(s)
@@ -93,6 +130,54 @@ func (s *registry) WithLogger(log logging.Logger) {
 
 func (s *registry) WithClient(c client.Client) {
 	s.client = c
+	if s.files == nil {
+		s.store = &clientStore{client: c}
+	}
+}
+
+func (s *registry) WithReader(r client.Reader) {
+	s.reader = r
+}
+
+func (s *registry) withDirectory(ctx context.Context, dir string) {
+	s.files = newFileStore(ctx, s.log, dir)
+	s.store = s.files
+}
+
+// clientStore reads organizations and deployments through a kubernetes
+// client, the state is maintained by the controllers.
+type clientStore struct {
+	client client.Client
+}
+
+func (s *clientStore) getOrganization(ctx context.Context, namespace, name string) (orgv1alpha1.Org, error) {
+	org := &orgv1alpha1.Organization{}
+	if err := s.client.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
+	}, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *clientStore) getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error) {
+	dep := &orgv1alpha1.Deployment{}
+	if err := s.client.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
+	}, dep); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+func (s *clientStore) listDeployments(ctx context.Context, namespace string) ([]orgv1alpha1.Dp, error) {
+	deps := &orgv1alpha1.DeploymentList{}
+	if err := s.client.List(ctx, deps, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return deps.GetDeployments(), nil
 }
 
 /*
@@ -109,76 +194,295 @@ func (r *registry) GetRegisterName(organizationName string, deploymentName strin
 */
 
 func (r *registry) GetRegister(ctx context.Context, mg resource.Managed) (map[string]string, error) {
-	// critical registers are ipam and as right now since they server dynamic
-	// grpc services
-	criticalRegisters := []string{
-		RegisterKindIpam.String(),
-		RegisterKindAs.String(),
-		RegisterKindNi.String(),
+	ctx, span := startSpan(ctx, "GetRegister", nameAttributes(mg.GetNamespace(), mg.GetName())...)
+	fullOdaName, err := odaNameOf(mg)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
 	}
+	registers, err := r.GetRegisterByName(ctx, mg.GetNamespace(), fullOdaName)
+	endSpan(span, err)
+	return registers, err
+}
 
-	o := odns.Name2OdnsResource(mg.GetName()).GetOdns()
-	fullOdaName, odaKind := o.GetFullOdaName()
-
-	var registers map[string]string
-	switch odaKind {
-	case nddv1.OdaKindDeployment:
-		dep := &orgv1alpha1.Deployment{}
-		if err := r.client.Get(ctx, types.NamespacedName{
-			Namespace: mg.GetNamespace(),
-			Name:      fullOdaName,
-		}, dep); err != nil {
-			return nil, err
-		}
+func (r *registry) GetRegisterByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	ctx, span := startSpan(ctx, "GetRegisterByName", nameAttributes(namespace, odaName)...)
+	registers, err := r.getRegisterByName(ctx, namespace, odaName)
+	endSpan(span, err)
+	return registers, err
+}
 
-		registers = dep.GetStateRegister()
-	default:
-		org := &orgv1alpha1.Organization{}
-		if err := r.client.Get(ctx, types.NamespacedName{
-			Namespace: mg.GetNamespace(),
-			Name:      fullOdaName,
-		}, org); err != nil {
-			return nil, err
-		}
-		registers = org.GetStateRegister()
+func (r *registry) getRegisterByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	if r.store == nil {
+		RegisterResolutionFailures.WithLabelValues(FailureReasonNoStore).Inc()
+		return nil, errors.New(errNoStore)
 	}
-	for _, register := range criticalRegisters {
-		if _, ok := registers[register]; !ok {
-			return nil, fmt.Errorf("critical register %s not found in registry", register)
-		}
+	o, err := r.getOda(ctx, namespace, odaName)
+	if err != nil {
+		return nil, resolutionFailed(err)
+	}
+	registers := o.GetStateRegister()
+	if err := ValidateRegister(registers, criticalRegisters(o)...); err != nil {
+		return nil, resolutionFailed(err)
 	}
 	return registers, nil
 }
 
 func (r *registry) GetAddressAllocationStrategy(ctx context.Context, mg resource.Managed) (*nddov1.AddressAllocationStrategy, error) {
+	ctx, span := startSpan(ctx, "GetAddressAllocationStrategy", nameAttributes(mg.GetNamespace(), mg.GetName())...)
+	fullOdaName, err := odaNameOf(mg)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	aas, err := r.GetAddressAllocationStrategyByName(ctx, mg.GetNamespace(), fullOdaName)
+	endSpan(span, err)
+	return aas, err
+}
 
-	o := odns.Name2OdnsResource(mg.GetName()).GetOdns()
-	fullOdaName, odaKind := o.GetFullOdaName()
-
-	switch odaKind {
-	case nddv1.OdaKindDeployment:
-		dep := &orgv1alpha1.Deployment{}
-		if err := r.client.Get(ctx, types.NamespacedName{
-			Namespace: mg.GetNamespace(),
-			Name:      fullOdaName,
-		}, dep); err != nil {
-			return nil, err
-		}
-		return dep.GetStateAddressAllocationStrategy(), nil
-
-	default:
-		org := &orgv1alpha1.Organization{}
-		if err := r.client.Get(ctx, types.NamespacedName{
-			Namespace: mg.GetNamespace(),
-			Name:      fullOdaName,
-		}, org); err != nil {
-			return nil, err
+func (r *registry) GetAddressAllocationStrategyByName(ctx context.Context, namespace, odaName string) (*nddov1.AddressAllocationStrategy, error) {
+	ctx, span := startSpan(ctx, "GetAddressAllocationStrategyByName", nameAttributes(namespace, odaName)...)
+	aas, err := r.getAddressAllocationStrategyByName(ctx, namespace, odaName)
+	endSpan(span, err)
+	return aas, err
+}
+
+func (r *registry) getAddressAllocationStrategyByName(ctx context.Context, namespace, odaName string) (*nddov1.AddressAllocationStrategy, error) {
+	if r.store == nil {
+		RegisterResolutionFailures.WithLabelValues(FailureReasonNoStore).Inc()
+		return nil, errors.New(errNoStore)
+	}
+	o, err := r.getOda(ctx, namespace, odaName)
+	if err != nil {
+		return nil, resolutionFailed(err)
+	}
+	return o.GetStateAddressAllocationStrategy(), nil
+}
+
+func (r *registry) GetIDs(ctx context.Context, mg resource.Managed) (IDs, error) {
+	ctx, span := startSpan(ctx, "GetIDs", nameAttributes(mg.GetNamespace(), mg.GetName())...)
+	fullOdaName, err := odaNameOf(mg)
+	if err != nil {
+		endSpan(span, err)
+		return IDs{}, err
+	}
+	ids, err := r.GetIDsByName(ctx, mg.GetNamespace(), fullOdaName)
+	endSpan(span, err)
+	return ids, err
+}
+
+func (r *registry) GetIDsByName(ctx context.Context, namespace, odaName string) (IDs, error) {
+	ctx, span := startSpan(ctx, "GetIDsByName", nameAttributes(namespace, odaName)...)
+	ids, err := r.getIDsByName(ctx, namespace, odaName)
+	endSpan(span, err)
+	return ids, err
+}
+
+func (r *registry) getIDsByName(ctx context.Context, namespace, odaName string) (IDs, error) {
+	if r.store == nil {
+		RegisterResolutionFailures.WithLabelValues(FailureReasonNoStore).Inc()
+		return IDs{}, errors.New(errNoStore)
+	}
+	o, err := r.getOda(ctx, namespace, odaName)
+	if err != nil {
+		return IDs{}, resolutionFailed(err)
+	}
+	ids := IDs{OrganizationID: o.GetStateOrganizationID()}
+	allocated := ids.OrganizationID != 0
+	if d, ok := o.(interface{ GetStateDeploymentID() int64 }); ok {
+		ids.DeploymentID = d.GetStateDeploymentID()
+		allocated = allocated && ids.DeploymentID != 0
+	}
+	if !allocated {
+		return IDs{}, resolutionFailed(fmt.Errorf(errNoIDs, odaName))
+	}
+	return ids, nil
+}
+
+func (r *registry) GetDerived(ctx context.Context, mg resource.Managed) (map[string]string, error) {
+	ctx, span := startSpan(ctx, "GetDerived", nameAttributes(mg.GetNamespace(), mg.GetName())...)
+	fullOdaName, err := odaNameOf(mg)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	derived, err := r.GetDerivedByName(ctx, mg.GetNamespace(), fullOdaName)
+	endSpan(span, err)
+	return derived, err
+}
+
+func (r *registry) GetDerivedByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	ctx, span := startSpan(ctx, "GetDerivedByName", nameAttributes(namespace, odaName)...)
+	derived, err := r.getDerivedByName(ctx, namespace, odaName)
+	endSpan(span, err)
+	return derived, err
+}
+
+func (r *registry) getDerivedByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	if r.store == nil {
+		RegisterResolutionFailures.WithLabelValues(FailureReasonNoStore).Inc()
+		return nil, errors.New(errNoStore)
+	}
+	o, err := r.getOda(ctx, namespace, odaName)
+	if err != nil {
+		return nil, resolutionFailed(err)
+	}
+	d, ok := o.(interface {
+		GetStateDeploymentID() int64
+		GetStateDerived() map[string]string
+	})
+	if !ok {
+		return nil, resolutionFailed(fmt.Errorf(errNoDerived, odaName))
+	}
+	// the identifiers are derived from the ids
+	if o.GetStateOrganizationID() == 0 || d.GetStateDeploymentID() == 0 {
+		return nil, resolutionFailed(fmt.Errorf(errNoIDs, odaName))
+	}
+	derived := make(map[string]string, len(d.GetStateDerived()))
+	for name, value := range d.GetStateDerived() {
+		derived[name] = value
+	}
+	return derived, nil
+}
+
+// oda is the organization or deployment that holds a register.
+type oda interface {
+	GetName() string
+	GetResourceVersion() string
+	GetStateRegister() map[string]string
+	GetStateAddressAllocationStrategy() *nddov1.AddressAllocationStrategy
+	GetStateOrganizationID() int64
+}
+
+// criticalRegisters returns the critical registers of the kind of a
+// deployment, organizations have none.
+func criticalRegisters(o oda) []string {
+	if d, ok := o.(interface{ GetStateCriticalRegisters() []string }); ok {
+		return d.GetStateCriticalRegisters()
+	}
+	return nil
+}
+
+// getOda returns the organization or deployment that holds the register of
+// the supplied odns name.
+func (r *registry) getOda(ctx context.Context, namespace, odaName string) (oda, error) {
+	kind, name, err := odaTarget(odaName)
+	if err != nil {
+		return nil, err
+	}
+	if kind == nddv1.OdaKindDeployment {
+		return r.getDeployment(ctx, namespace, name)
+	}
+	return r.store.getOrganization(ctx, namespace, name)
+}
+
+// getDeployment returns the deployment of an odns name. A deployment that
+// references its organization does not need the odns name as its name, it is
+// found by its organization and deployment name.
+func (r *registry) getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error) {
+	dep, err := r.store.getDeployment(ctx, namespace, name)
+	if kerrors.IsNotFound(err) {
+		dep, err = r.findDeployment(ctx, namespace, name, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := dep.ValidateOrganizationRef(); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+// findDeployment returns the deployment that references the organization of
+// the odns name, notFound is returned when there is none.
+func (r *registry) findDeployment(ctx context.Context, namespace, name string, notFound error) (orgv1alpha1.Dp, error) {
+	o := odns.Name2Odns(name)
+	deps, err := r.store.listDeployments(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range deps {
+		if dep.GetOrganizationName() == o.GetOrganization() && dep.GetDeploymentName() == o.GetDeployment() {
+			return dep, nil
 		}
-		return org.GetStateAddressAllocationStrategy(), nil
 	}
+	return nil, notFound
+}
+
+// odaNameOf returns the odns name of the organization or deployment a managed
+// resource belongs to, e.g. nokia.dc1 for the resource nokia.dc1.leaf1. A
+// name without a dot does not belong to an organization.
+func odaNameOf(mg resource.Managed) (string, error) {
+	o := odns.Name2OdnsResource(mg.GetName()).GetOdns()
+	if o == nil {
+		return "", fmt.Errorf(errInvalidOdaName, mg.GetName())
+	}
+	fullOdaName, _ := o.GetFullOdaName()
+	return fullOdaName, nil
+}
+
+// odaTarget returns the kind and name of the resource that holds the register
+// of an odns name. A name without dots is an organization, any other name
+// resolves to the deployment of its first two segments: an availability
+// zone shares the register of its deployment and further segments are
+// ignored. Names are not normalized, unicode is looked up as is.
+func odaTarget(odaName string) (nddv1.OdaKind, string, error) {
+	o := odns.Name2Odns(odaName)
+	switch {
+	case o.GetOrganization() == "":
+		return nddv1.OdaKindUnknown, "", fmt.Errorf(errInvalidOdaName, odaName)
+	case o.GetDeployment() == "":
+		return nddv1.OdaKindOrganization, o.GetOrganization(), nil
+	}
+	return nddv1.OdaKindDeployment, strings.Join([]string{o.GetOrganization(), o.GetDeployment()}, "."), nil
 }
 
 func (r *registry) GetRegistryClient(ctx context.Context, registerName string) (resourcepb.ResourceClient, error) {
+	ctx, span := startSpan(ctx, "GetRegistryClient", attribute.String("register", registerName))
+	rc, err := r.getRegistryClient(ctx, registerName)
+	endSpan(span, err)
+	return rc, err
+}
+
+func (r *registry) getRegistryClient(ctx context.Context, registerName string) (resourcepb.ResourceClient, error) {
+	start := time.Now()
+	address, err := r.GetRegistryEndpoint(ctx, registerName)
+	RegistryClientDiscoveryDuration.WithLabelValues(registerName).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	// the connection of a client cannot be closed, the client of an address
+	// is dialed once and reused
+	r.m.Lock()
+	defer r.m.Unlock()
+	if rc, ok := r.clients[address]; ok {
+		return rc, nil
+	}
+
+	ctx, span := startSpan(ctx, "dial", attribute.String("address", address))
+	start = time.Now()
+	rc, err := getResourceClient(ctx, address)
+	RegistryClientDialDuration.WithLabelValues(registerName).Observe(time.Since(start).Seconds())
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if r.clients == nil {
+		r.clients = make(map[string]resourcepb.ResourceClient)
+	}
+	r.clients[address] = rc
+	return rc, nil
+}
+
+func (r *registry) GetRegistryEndpoint(ctx context.Context, registerName string) (string, error) {
+	ctx, span := startSpan(ctx, "GetRegistryEndpoint", attribute.String("register", registerName))
+	address, err := r.getRegistryEndpoint(ctx, registerName)
+	endSpan(span, err)
+	return address, err
+}
+
+func (r *registry) getRegistryEndpoint(ctx context.Context, registerName string) (string, error) {
 	registers := map[string]string{
 		RegisterKindIpam.String(): "nddr-ipam-registry",
 		RegisterKindAs.String():   "nddr-as-registry",
@@ -186,7 +490,11 @@ func (r *registry) GetRegistryClient(ctx context.Context, registerName string) (
 	}
 
 	if _, ok := registers[registerName]; !ok {
-		return nil, fmt.Errorf("wrong register request, name not found: %s", registerName)
+		return "", fmt.Errorf("wrong register request, name not found: %s", registerName)
+	}
+	if r.reader == nil {
+		// the registry services are discovered through kubernetes
+		return "", errors.New(errNoReader)
 	}
 	registerMatch := registers[registerName]
 
@@ -194,8 +502,8 @@ func (r *registry) GetRegistryClient(ctx context.Context, registerName string) (
 	opts := []client.ListOption{
 		client.InNamespace(nddNamespace),
 	}
-	if err := r.client.List(ctx, pods, opts...); err != nil {
-		return nil, err
+	if err := r.reader.List(ctx, pods, opts...); err != nil {
+		return "", err
 	}
 
 	var podname string
@@ -210,25 +518,28 @@ func (r *registry) GetRegistryClient(ctx context.Context, registerName string) (
 		}
 	}
 	if !found {
-		return nil, fmt.Errorf("no pod that matches %s, %s", registerName, registerMatch)
+		return "", fmt.Errorf("no pod that matches %s, %s", registerName, registerMatch)
 	}
 
-	return getResourceClient(ctx, getGrpcServerName(podname))
-
+	return getGrpcServerName(podname), nil
 }
 
+// getGrpcServerName returns the address of the grpc service of a registry
+// from the name of its pod. The pod belongs to a kubernetes deployment, the
+// replicaset hash and pod suffix, the last two segments of the name, are
+// dropped. Names with fewer than three segments keep their first segment.
 func getGrpcServerName(podName string) string {
-	var newName string
-	for i, s := range strings.Split(podName, "-") {
-		if i == 0 {
-			newName = s
-		} else if i <= (len(strings.Split(podName, "-")) - 3) {
-			newName += "-" + s
-		}
+	segments := strings.Split(podName, "-")
+	name := segments[0]
+	if len(segments) >= 3 {
+		name = strings.Join(segments[:len(segments)-2], "-")
 	}
-	return pkgmetav1.PrefixGnmiService + "-" + newName + "." + pkgmetav1.NamespaceLocalK8sDNS + strconv.Itoa((pkgmetav1.GnmiServerPort))
+	return pkgmetav1.PrefixGnmiService + "-" + name + "." + pkgmetav1.NamespaceLocalK8sDNS + strconv.Itoa((pkgmetav1.GnmiServerPort))
 }
 
+// getResourceClient returns a client of the registry at grpcserver, the
+// trace context of the calls made with the client is propagated to the
+// registry.
 func getResourceClient(ctx context.Context, grpcserver string) (resourcepb.ResourceClient, error) {
 	cfg := &ndd.Config{
 		Address:  grpcserver,
@@ -241,5 +552,9 @@ func getResourceClient(ctx context.Context, grpcserver string) (resourcepb.Resou
 		TLSCert:    "",
 		TLSKey:     "",
 	}
-	return rclient.NewClient(ctx, cfg)
+	rc, err := rclient.NewClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tracedClient{ResourceClient: rc, address: grpcserver}, nil
 }
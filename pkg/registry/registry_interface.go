@@ -23,6 +23,8 @@ import (
 	"github.com/yndd/ndd-runtime/pkg/resource"
 	"github.com/yndd/nddo-grpc/resource/resourcepb"
 	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -42,11 +44,69 @@ func WithClient(c client.Client) Option {
 	}
 }
 
+// WithReader specifies the reader the registry services are discovered with,
+// typically the api reader of the manager so that the pods are not cached.
+func WithReader(r client.Reader) Option {
+	return func(s Registry) {
+		s.WithReader(r)
+	}
+}
+
+// WithDirectory serves the registry from a directory of Organization and
+// Deployment yaml files instead of kubernetes, the DeploymentClass,
+// DeploymentKindDefinition and RegisterPolicy files of the directory apply
+// to the deployments. The directory is watched and reloaded on change until
+// ctx is done. The option only applies to the Registry returned by New.
+func WithDirectory(ctx context.Context, dir string) Option {
+	return func(s Registry) {
+		if r, ok := s.(*registry); ok {
+			r.withDirectory(ctx, dir)
+		}
+	}
+}
+
+// WithInformers specifies the informers that notify watches of changes,
+// typically the cache of the manager.
+func WithInformers(i cache.Informers) Option {
+	return func(s Registry) {
+		s.WithInformers(i)
+	}
+}
+
+// IDs are the numeric ids allocated to an organization or deployment. The
+// organization id is unique in the cluster, the deployment id is unique in
+// the organization and 0 for an organization.
+type IDs struct {
+	OrganizationID int64
+	DeploymentID   int64
+}
+
 type Registry interface {
 	WithLogger(logging.Logger)
 	WithClient(client.Client)
+	WithReader(client.Reader)
+	WithInformers(cache.Informers)
 	//GetRegisterName(*nddov1.OdaInfo) []string
 	GetRegister(context.Context, resource.Managed) (map[string]string, error)
+	// GetRegisterByName returns the register of an organization or deployment
+	// by odns name, e.g. <organization> or <organization>.<deployment>
+	GetRegisterByName(ctx context.Context, namespace, odaName string) (map[string]string, error)
 	GetAddressAllocationStrategy(context.Context, resource.Managed) (*nddov1.AddressAllocationStrategy, error)
+	GetAddressAllocationStrategyByName(ctx context.Context, namespace, odaName string) (*nddov1.AddressAllocationStrategy, error)
+	GetIDs(context.Context, resource.Managed) (IDs, error)
+	// GetIDsByName returns the ids of an organization or deployment by odns
+	// name, an error is returned until the ids are allocated
+	GetIDsByName(ctx context.Context, namespace, odaName string) (IDs, error)
+	GetDerived(context.Context, resource.Managed) (map[string]string, error)
+	// GetDerivedByName returns the derived identifiers of a deployment by odns
+	// name, e.g. the route target base rendered from its organization
+	GetDerivedByName(ctx context.Context, namespace, odaName string) (map[string]string, error)
 	GetRegistryClient(ctx context.Context, registerName string) (resourcepb.ResourceClient, error)
+	// GetRegistryEndpoint returns the grpc address of the registry that serves
+	// the register
+	GetRegistryEndpoint(ctx context.Context, registerName string) (string, error)
+	// Watch returns a channel that receives the effective register of the
+	// organization or deployment with the supplied odns name, initially and
+	// whenever it changes. The channel is closed when the context is done.
+	Watch(ctx context.Context, name types.NamespacedName, opts ...WatchOption) (<-chan RegisterEvent, error)
 }
@@ -0,0 +1,591 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	pkgmetav1 "github.com/yndd/ndd-core/apis/pkg/meta/v1"
+	nddv1 "github.com/yndd/ndd-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func grpcServerName(service string) string {
+	return pkgmetav1.PrefixGnmiService + "-" + service + "." + pkgmetav1.NamespaceLocalK8sDNS + strconv.Itoa(pkgmetav1.GnmiServerPort)
+}
+
+func TestGetGrpcServerName(t *testing.T) {
+	cases := map[string]struct {
+		podName string
+		want    string
+	}{
+		"DeploymentPod": {
+			podName: "nddr-ipam-registry-7d9f8c6b5-x2k4q",
+			want:    grpcServerName("nddr-ipam-registry"),
+		},
+		"SingleSegmentDeployment": {
+			podName: "registry-7d9f8c6b5-x2k4q",
+			want:    grpcServerName("registry"),
+		},
+		"TwoSegmentsKeepTheFirst": {
+			podName: "registry-0",
+			want:    grpcServerName("registry"),
+		},
+		"NoDashes": {
+			podName: "registry",
+			want:    grpcServerName("registry"),
+		},
+		"Empty": {
+			podName: "",
+			want:    grpcServerName(""),
+		},
+		"EmptySegments": {
+			podName: "nddr--registry--",
+			want:    grpcServerName("nddr--registry"),
+		},
+		"Unicode": {
+			podName: "nddr-régistry-7d9f8c6b5-x2k4q",
+			want:    grpcServerName("nddr-régistry"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := getGrpcServerName(tc.podName); got != tc.want {
+				t.Errorf("getGrpcServerName(%q): want %q, got %q", tc.podName, tc.want, got)
+			}
+		})
+	}
+}
+
+func FuzzGetGrpcServerName(f *testing.F) {
+	for _, seed := range []string{"nddr-ipam-registry-7d9f8c6b5-x2k4q", "registry-0", "registry", "", "---", "é-ü-ñ"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, podName string) {
+		got := getGrpcServerName(podName)
+		prefix := pkgmetav1.PrefixGnmiService + "-"
+		suffix := "." + pkgmetav1.NamespaceLocalK8sDNS + strconv.Itoa(pkgmetav1.GnmiServerPort)
+		if !strings.HasPrefix(got, prefix) || !strings.HasSuffix(got, suffix) {
+			t.Fatalf("getGrpcServerName(%q) = %q: not a service address", podName, got)
+		}
+		service := strings.TrimSuffix(strings.TrimPrefix(got, prefix), suffix)
+		// the service is the pod name minus the last two segments, or the
+		// first segment of shorter names
+		if !strings.HasPrefix(podName, service) {
+			t.Fatalf("getGrpcServerName(%q) = %q: service is not a prefix of the pod name", podName, got)
+		}
+		want := 2
+		if n := strings.Count(podName, "-"); n < 2 {
+			want = n
+		}
+		if dropped := strings.Count(podName, "-") - strings.Count(service, "-"); dropped != want {
+			t.Fatalf("getGrpcServerName(%q) = %q: dropped %d segments, want %d", podName, got, dropped, want)
+		}
+	})
+}
+
+func TestOdaTarget(t *testing.T) {
+	cases := map[string]struct {
+		odaName  string
+		wantKind nddv1.OdaKind
+		wantName string
+		wantErr  bool
+	}{
+		"Organization": {
+			odaName:  "nokia",
+			wantKind: nddv1.OdaKindOrganization,
+			wantName: "nokia",
+		},
+		"Deployment": {
+			odaName:  "nokia.dc1",
+			wantKind: nddv1.OdaKindDeployment,
+			wantName: "nokia.dc1",
+		},
+		"AvailabilityZoneResolvesToDeployment": {
+			odaName:  "nokia.dc1.az1",
+			wantKind: nddv1.OdaKindDeployment,
+			wantName: "nokia.dc1",
+		},
+		"ExtraSegmentsAreIgnored": {
+			odaName:  "nokia.dc1.az1.leaf1.extra",
+			wantKind: nddv1.OdaKindDeployment,
+			wantName: "nokia.dc1",
+		},
+		"TrailingDotIsOrganization": {
+			odaName:  "nokia.",
+			wantKind: nddv1.OdaKindOrganization,
+			wantName: "nokia",
+		},
+		"Unicode": {
+			odaName:  "nökia.dc-ü",
+			wantKind: nddv1.OdaKindDeployment,
+			wantName: "nökia.dc-ü",
+		},
+		"Empty": {
+			odaName: "",
+			wantErr: true,
+		},
+		"EmptyOrganization": {
+			odaName: ".dc1",
+			wantErr: true,
+		},
+		"Dot": {
+			odaName: ".",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kind, got, err := odaTarget(tc.odaName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("odaTarget(%q): want error %t, got %v", tc.odaName, tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if kind != tc.wantKind || got != tc.wantName {
+				t.Errorf("odaTarget(%q): want %s %q, got %s %q", tc.odaName, tc.wantKind, tc.wantName, kind, got)
+			}
+		})
+	}
+}
+
+func FuzzOdaTarget(f *testing.F) {
+	for _, seed := range []string{"nokia", "nokia.dc1", "nokia.dc1.az1", "nokia.dc1.az1.x", "", ".", "..", "nökia.ü"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, odaName string) {
+		kind, name, err := odaTarget(odaName)
+		if err != nil {
+			if !strings.HasPrefix(odaName, ".") && odaName != "" {
+				t.Fatalf("odaTarget(%q): unexpected error %v", odaName, err)
+			}
+			return
+		}
+		if !strings.HasPrefix(odaName, name) {
+			t.Fatalf("odaTarget(%q) = %q: not a prefix of the name", odaName, name)
+		}
+		switch kind {
+		case nddv1.OdaKindOrganization:
+			if strings.Contains(name, ".") {
+				t.Fatalf("odaTarget(%q) = organization %q with a dot", odaName, name)
+			}
+		case nddv1.OdaKindDeployment:
+			if strings.Count(name, ".") != 1 {
+				t.Fatalf("odaTarget(%q) = deployment %q without exactly one dot", odaName, name)
+			}
+		default:
+			t.Fatalf("odaTarget(%q): unexpected kind %s", odaName, kind)
+		}
+	})
+}
+
+// lookupStore records the lookups of the registry and serves a valid
+// register for every name.
+type lookupStore struct {
+	lookups []string
+}
+
+func (s *lookupStore) getOrganization(ctx context.Context, namespace, name string) (orgv1alpha1.Org, error) {
+	s.lookups = append(s.lookups, "organization/"+namespace+"/"+name)
+	if name == "missing" {
+		return nil, kerrors.NewNotFound(schema.GroupResource{Group: orgv1alpha1.Group, Resource: "organizations"}, name)
+	}
+	cr := &orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	_ = cr.InitializeResource()
+	cr.SetStatus("up")
+	cr.SetStateRegister(map[string]string{"ipam": name, "as": name, "ni": name})
+	return cr, nil
+}
+
+func (s *lookupStore) getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error) {
+	s.lookups = append(s.lookups, "deployment/"+namespace+"/"+name)
+	cr := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	_ = cr.InitializeResource()
+	cr.SetStatus("up")
+	cr.SetStateRegister(map[string]string{"ipam": name, "as": name, "ni": name})
+	return cr, nil
+}
+
+func (s *lookupStore) listDeployments(ctx context.Context, namespace string) ([]orgv1alpha1.Dp, error) {
+	s.lookups = append(s.lookups, "deployments/"+namespace)
+	return nil, nil
+}
+
+func TestGetRegisterByNameDispatch(t *testing.T) {
+	cases := map[string]struct {
+		odaName     string
+		wantLookups []string
+		wantName    string
+		wantErr     bool
+	}{
+		"Organization": {
+			odaName:     "nokia",
+			wantLookups: []string{"organization/default/nokia"},
+			wantName:    "nokia",
+		},
+		"Deployment": {
+			odaName:     "nokia.dc1",
+			wantLookups: []string{"deployment/default/nokia.dc1"},
+			wantName:    "nokia.dc1",
+		},
+		"AvailabilityZone": {
+			odaName:     "nokia.dc1.az1",
+			wantLookups: []string{"deployment/default/nokia.dc1"},
+			wantName:    "nokia.dc1",
+		},
+		"NotFound": {
+			odaName:     "missing",
+			wantLookups: []string{"organization/default/missing"},
+			wantErr:     true,
+		},
+		"InvalidNameIsNotLookedUp": {
+			odaName: ".dc1",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &lookupStore{}
+			r := &registry{store: s}
+			got, err := r.GetRegisterByName(context.Background(), "default", tc.odaName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetRegisterByName(%q): want error %t, got %v", tc.odaName, tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(s.lookups, tc.wantLookups) {
+				t.Errorf("GetRegisterByName(%q): want lookups %v, got %v", tc.odaName, tc.wantLookups, s.lookups)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got["ipam"] != tc.wantName {
+				t.Errorf("GetRegisterByName(%q): want register of %q, got %v", tc.odaName, tc.wantName, got)
+			}
+		})
+	}
+}
+
+func FuzzGetRegisterByName(f *testing.F) {
+	for _, seed := range []string{"nokia", "nokia.dc1", "nokia.dc1.az1", "", ".", "nökia.ü"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, odaName string) {
+		s := &lookupStore{}
+		r := &registry{store: s}
+		_, err := r.GetRegisterByName(context.Background(), "default", odaName)
+		if err != nil {
+			if len(s.lookups) != 0 && !strings.HasSuffix(s.lookups[0], "/missing") {
+				t.Fatalf("GetRegisterByName(%q): looked up %v and failed: %v", odaName, s.lookups, err)
+			}
+			return
+		}
+		if len(s.lookups) != 1 {
+			t.Fatalf("GetRegisterByName(%q): want exactly one lookup, got %v", odaName, s.lookups)
+		}
+	})
+}
+
+// depStore serves the supplied deployments, organizations do not exist.
+type depStore struct {
+	deps []*orgv1alpha1.Deployment
+}
+
+func (s *depStore) getOrganization(ctx context.Context, namespace, name string) (orgv1alpha1.Org, error) {
+	return nil, kerrors.NewNotFound(schema.GroupResource{Group: orgv1alpha1.Group, Resource: "organizations"}, name)
+}
+
+func (s *depStore) getDeployment(ctx context.Context, namespace, name string) (orgv1alpha1.Dp, error) {
+	for _, dep := range s.deps {
+		if dep.GetNamespace() == namespace && dep.GetName() == name {
+			return dep, nil
+		}
+	}
+	return nil, kerrors.NewNotFound(schema.GroupResource{Group: orgv1alpha1.Group, Resource: "deployments"}, name)
+}
+
+func (s *depStore) listDeployments(ctx context.Context, namespace string) ([]orgv1alpha1.Dp, error) {
+	var deps []orgv1alpha1.Dp
+	for _, dep := range s.deps {
+		if dep.GetNamespace() == namespace {
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+func refDeployment(name string, ref *orgv1alpha1.OrganizationReference) *orgv1alpha1.Deployment {
+	cr := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	cr.Spec.Properties.OrganizationRef = ref
+	_ = cr.InitializeResource()
+	cr.SetStatus("up")
+	cr.SetStateRegister(map[string]string{"ipam": name, "as": name, "ni": name})
+	return cr
+}
+
+func TestGetRegisterByNameOrganizationRef(t *testing.T) {
+	cases := map[string]struct {
+		deps     []*orgv1alpha1.Deployment
+		odaName  string
+		wantName string
+		wantErr  bool
+	}{
+		"OdnsName": {
+			deps:     []*orgv1alpha1.Deployment{refDeployment("nokia.dc1", nil)},
+			odaName:  "nokia.dc1",
+			wantName: "nokia.dc1",
+		},
+		"PlainNameWithReference": {
+			deps:     []*orgv1alpha1.Deployment{refDeployment("dc1", &orgv1alpha1.OrganizationReference{Name: "nokia"})},
+			odaName:  "nokia.dc1.az1",
+			wantName: "dc1",
+		},
+		"ReferenceOfAnotherOrganization": {
+			deps:    []*orgv1alpha1.Deployment{refDeployment("dc1", &orgv1alpha1.OrganizationReference{Name: "acme"})},
+			odaName: "nokia.dc1",
+			wantErr: true,
+		},
+		"ReferenceInAnotherNamespace": {
+			deps:     []*orgv1alpha1.Deployment{refDeployment("dc1", &orgv1alpha1.OrganizationReference{Name: "nokia", Namespace: "orgs"})},
+			odaName:  "nokia.dc1",
+			wantName: "dc1",
+		},
+		"Mismatch": {
+			deps:    []*orgv1alpha1.Deployment{refDeployment("nokia.dc1", &orgv1alpha1.OrganizationReference{Name: "acme"})},
+			odaName: "nokia.dc1",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &registry{store: &depStore{deps: tc.deps}}
+			got, err := r.GetRegisterByName(context.Background(), "default", tc.odaName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetRegisterByName(%q): want error %t, got %v", tc.odaName, tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got["ipam"] != tc.wantName {
+				t.Errorf("GetRegisterByName(%q): want register of %q, got %v", tc.odaName, tc.wantName, got)
+			}
+		})
+	}
+}
+
+func TestGetRegisterByNameKindCriticalRegisters(t *testing.T) {
+	cases := map[string]struct {
+		critical []string
+		register map[string]string
+		wantErr  bool
+	}{
+		"NoKindCriticalRegisters": {
+			register: map[string]string{"ipam": "a", "as": "a", "ni": "a"},
+		},
+		"KindCriticalRegisterPresent": {
+			critical: []string{"esi"},
+			register: map[string]string{"ipam": "a", "as": "a", "ni": "a", "esi": "a"},
+		},
+		"KindCriticalRegisterMissing": {
+			critical: []string{"esi"},
+			register: map[string]string{"ipam": "a", "as": "a", "ni": "a"},
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dep := refDeployment("nokia.edge1", nil)
+			dep.SetStateRegister(tc.register)
+			dep.SetStateCriticalRegisters(tc.critical)
+			r := &registry{store: &depStore{deps: []*orgv1alpha1.Deployment{dep}}}
+			_, err := r.GetRegisterByName(context.Background(), "default", "nokia.edge1")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetRegisterByName(): want error %t, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr && !IsCriticalRegisterError(err) {
+				t.Errorf("GetRegisterByName(): want a critical register error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetIDsByName(t *testing.T) {
+	cases := map[string]struct {
+		orgID   int64
+		depID   int64
+		want    IDs
+		wantErr bool
+	}{
+		"Allocated": {
+			orgID: 3,
+			depID: 7,
+			want:  IDs{OrganizationID: 3, DeploymentID: 7},
+		},
+		"DeploymentIDNotAllocated": {
+			orgID:   3,
+			wantErr: true,
+		},
+		"OrganizationIDNotAllocated": {
+			depID:   7,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dep := refDeployment("nokia.dc1", nil)
+			dep.SetStateOrganizationID(tc.orgID)
+			dep.SetStateDeploymentID(tc.depID)
+			r := &registry{store: &depStore{deps: []*orgv1alpha1.Deployment{dep}}}
+			got, err := r.GetIDsByName(context.Background(), "default", "nokia.dc1")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetIDsByName(): want error %t, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("GetIDsByName(): want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetDerivedByName(t *testing.T) {
+	cases := map[string]struct {
+		depID   int64
+		derived map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		"Derived": {
+			depID:   7,
+			derived: map[string]string{"rt-base": "65000:700"},
+			want:    map[string]string{"rt-base": "65000:700"},
+		},
+		"NoTemplates": {
+			depID: 7,
+			want:  map[string]string{},
+		},
+		"IDsNotAllocated": {
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dep := refDeployment("nokia.dc1", nil)
+			dep.SetStateOrganizationID(3)
+			dep.SetStateDeploymentID(tc.depID)
+			dep.SetStateDerived(tc.derived)
+			r := &registry{store: &depStore{deps: []*orgv1alpha1.Deployment{dep}}}
+			got, err := r.GetDerivedByName(context.Background(), "default", "nokia.dc1")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetDerivedByName(): want error %t, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("GetDerivedByName(): want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRegister(t *testing.T) {
+	cases := map[string]struct {
+		name        string
+		wantLookups []string
+		wantErr     bool
+	}{
+		"OrganizationResource": {
+			name:        "nokia.leaf1",
+			wantLookups: []string{"organization/default/nokia"},
+		},
+		"DeploymentResource": {
+			name:        "nokia.dc1.leaf1",
+			wantLookups: []string{"deployment/default/nokia.dc1"},
+		},
+		"AvailabilityZoneResource": {
+			name:        "nokia.dc1.az1.leaf1",
+			wantLookups: []string{"deployment/default/nokia.dc1"},
+		},
+		"Dotless": {
+			name:    "leaf1",
+			wantErr: true,
+		},
+		"Empty": {
+			name:    "",
+			wantErr: true,
+		},
+		"EmptyOrganization": {
+			name:    ".leaf1",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &lookupStore{}
+			r := &registry{store: s}
+			mg := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: tc.name}}
+			_, err := r.GetRegister(context.Background(), mg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetRegister(%q): want error %t, got %v", tc.name, tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(s.lookups, tc.wantLookups) {
+				t.Errorf("GetRegister(%q): want lookups %v, got %v", tc.name, tc.wantLookups, s.lookups)
+			}
+			if _, err := r.GetAddressAllocationStrategy(context.Background(), mg); (err != nil) != tc.wantErr {
+				t.Errorf("GetAddressAllocationStrategy(%q): want error %t, got %v", tc.name, tc.wantErr, err)
+			}
+			if tc.wantErr {
+				// the ids and derived identifiers are not allocated by the
+				// store, only the name is checked
+				if _, err := r.GetIDs(context.Background(), mg); err == nil {
+					t.Errorf("GetIDs(%q): want error", tc.name)
+				}
+				if _, err := r.GetDerived(context.Background(), mg); err == nil {
+					t.Errorf("GetDerived(%q): want error", tc.name)
+				}
+			}
+		})
+	}
+}
+
+func FuzzGetRegister(f *testing.F) {
+	for _, seed := range []string{"leaf1", "nokia.leaf1", "nokia.dc1.leaf1", "nokia.dc1.az1.leaf1", "", ".", "..", ".leaf1"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		r := &registry{store: &lookupStore{}}
+		mg := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		_, err := r.GetRegister(context.Background(), mg)
+		if !strings.Contains(name, ".") && err == nil {
+			t.Fatalf("GetRegister(%q): want an error for a name without organization", name)
+		}
+		_, _ = r.GetAddressAllocationStrategy(context.Background(), mg)
+		_, _ = r.GetIDs(context.Background(), mg)
+		_, _ = r.GetDerived(context.Background(), mg)
+	})
+}
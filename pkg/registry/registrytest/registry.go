@@ -0,0 +1,428 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registrytest provides an in-memory registry.Registry for the tests
+// of packages that consume the org registry.
+package registrytest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/yndd/app-runtime/pkg/odns"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	"github.com/yndd/ndd-runtime/pkg/resource"
+	"github.com/yndd/nddo-grpc/resource/resourcepb"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	"github.com/yndd/nddr-org-registry/pkg/registry"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Method identifies a Registry method for error injection.
+type Method string
+
+const (
+	MethodGetRegister                  Method = "GetRegister"
+	MethodGetAddressAllocationStrategy Method = "GetAddressAllocationStrategy"
+	MethodGetIDs                       Method = "GetIDs"
+	MethodGetDerived                   Method = "GetDerived"
+	MethodGetRegistryClient            Method = "GetRegistryClient"
+	MethodWatch                        Method = "Watch"
+)
+
+var _ registry.Registry = &Registry{}
+
+// Option can be used to manipulate the Registry.
+type Option func(*Registry)
+
+// WithRegister sets the effective register returned for the organization or
+// deployment with the supplied odns name, e.g. "nokia" or "nokia.region1".
+func WithRegister(odaName string, register map[string]string) Option {
+	return func(r *Registry) {
+		r.SetRegister(odaName, register)
+	}
+}
+
+// WithAddressAllocationStrategy sets the address allocation strategy returned
+// for the organization or deployment with the supplied odns name.
+func WithAddressAllocationStrategy(odaName string, aas *nddov1.AddressAllocationStrategy) Option {
+	return func(r *Registry) {
+		r.SetAddressAllocationStrategy(odaName, aas)
+	}
+}
+
+// WithIDs sets the ids returned for the organization or deployment with the
+// supplied odns name.
+func WithIDs(odaName string, ids registry.IDs) Option {
+	return func(r *Registry) {
+		r.SetIDs(odaName, ids)
+	}
+}
+
+// WithDerived sets the derived identifiers returned for the deployment with
+// the supplied odns name.
+func WithDerived(odaName string, derived map[string]string) Option {
+	return func(r *Registry) {
+		r.SetDerived(odaName, derived)
+	}
+}
+
+// WithError makes every call of the supplied method fail with err.
+func WithError(m Method, err error) Option {
+	return func(r *Registry) {
+		r.SetError(m, err)
+	}
+}
+
+// WithResourceServer serves the supplied register kind with srv instead of
+// the default in-memory ResourceServer.
+func WithResourceServer(registerName string, srv resourcepb.ResourceServer) Option {
+	return func(r *Registry) {
+		r.servers[registerName] = srv
+	}
+}
+
+// WithoutValidation disables the critical register validation GetRegister
+// applies.
+func WithoutValidation() Option {
+	return func(r *Registry) {
+		r.skipValidation = true
+	}
+}
+
+// Registry is a configurable in-memory registry.Registry. Registers and
+// address allocation strategies are keyed by odns name and resolved with the
+// same name dispatch as the kubernetes backed registry. GetRegistryClient
+// returns a real grpc client connected over a bufconn listener to an
+// in-process ResourceServer.
+type Registry struct {
+	log logging.Logger
+
+	m              sync.Mutex
+	registers      map[string]map[string]string
+	strategies     map[string]*nddov1.AddressAllocationStrategy
+	ids            map[string]registry.IDs
+	derived        map[string]map[string]string
+	errs           map[Method]error
+	skipValidation bool
+
+	servers   map[string]resourcepb.ResourceServer
+	listeners map[string]*listener
+
+	// version is increased on every change and used as resume token
+	version uint64
+	watches map[*watch]struct{}
+}
+
+type watch struct {
+	name types.NamespacedName
+	ch   chan registry.RegisterEvent
+}
+
+// New returns an in-memory Registry.
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		log:        logging.NewNopLogger(),
+		registers:  make(map[string]map[string]string),
+		strategies: make(map[string]*nddov1.AddressAllocationStrategy),
+		ids:        make(map[string]registry.IDs),
+		derived:    make(map[string]map[string]string),
+		errs:       make(map[Method]error),
+		servers:    make(map[string]resourcepb.ResourceServer),
+		listeners:  make(map[string]*listener),
+		watches:    make(map[*watch]struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Registry) WithLogger(log logging.Logger) {
+	r.log = log
+}
+
+// WithClient is a no-op, the Registry does not use kubernetes.
+func (r *Registry) WithClient(c client.Client) {}
+
+// WithReader is a no-op, the registries are served in-process.
+func (r *Registry) WithReader(rd client.Reader) {}
+
+// WithInformers is a no-op, watches are notified by the setters.
+func (r *Registry) WithInformers(i cache.Informers) {}
+
+// SetRegister sets the register of the supplied odns name.
+func (r *Registry) SetRegister(odaName string, register map[string]string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	reg := make(map[string]string, len(register))
+	for kind, name := range register {
+		reg[kind] = name
+	}
+	r.registers[odaName] = reg
+	r.changed(odaName)
+}
+
+// SetAddressAllocationStrategy sets the address allocation strategy of the
+// supplied odns name.
+func (r *Registry) SetAddressAllocationStrategy(odaName string, aas *nddov1.AddressAllocationStrategy) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.strategies[odaName] = aas
+	r.changed(odaName)
+}
+
+// SetIDs sets the ids of the supplied odns name.
+func (r *Registry) SetIDs(odaName string, ids registry.IDs) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.ids[odaName] = ids
+}
+
+// SetDerived sets the derived identifiers of the supplied odns name.
+func (r *Registry) SetDerived(odaName string, derived map[string]string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	d := make(map[string]string, len(derived))
+	for name, value := range derived {
+		d[name] = value
+	}
+	r.derived[odaName] = d
+}
+
+// SetError sets or, when err is nil, clears the error returned by the method.
+func (r *Registry) SetError(m Method, err error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err == nil {
+		delete(r.errs, m)
+		return
+	}
+	r.errs[m] = err
+}
+
+// ResourceServer returns the in-memory ResourceServer that serves the supplied
+// register kind. It returns nil if the register is served by a server
+// supplied through WithResourceServer.
+func (r *Registry) ResourceServer(registerName string) *ResourceServer {
+	r.m.Lock()
+	defer r.m.Unlock()
+	srv, ok := r.servers[registerName]
+	if !ok {
+		srv = NewResourceServer()
+		r.servers[registerName] = srv
+	}
+	s, _ := srv.(*ResourceServer)
+	return s
+}
+
+func (r *Registry) GetRegister(ctx context.Context, mg resource.Managed) (map[string]string, error) {
+	return r.GetRegisterByName(ctx, mg.GetNamespace(), getOdaName(mg))
+}
+
+func (r *Registry) GetRegisterByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetRegister]; err != nil {
+		return nil, err
+	}
+	return r.getRegister(odaName)
+}
+
+func (r *Registry) getRegister(odaName string) (map[string]string, error) {
+	reg, ok := r.registers[odaName]
+	if !ok {
+		return nil, fmt.Errorf("no register for %s", odaName)
+	}
+	if !r.skipValidation {
+		if err := registry.ValidateRegister(reg); err != nil {
+			return nil, err
+		}
+	}
+	register := make(map[string]string, len(reg))
+	for kind, name := range reg {
+		register[kind] = name
+	}
+	return register, nil
+}
+
+func (r *Registry) GetAddressAllocationStrategy(ctx context.Context, mg resource.Managed) (*nddov1.AddressAllocationStrategy, error) {
+	return r.GetAddressAllocationStrategyByName(ctx, mg.GetNamespace(), getOdaName(mg))
+}
+
+func (r *Registry) GetAddressAllocationStrategyByName(ctx context.Context, namespace, odaName string) (*nddov1.AddressAllocationStrategy, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetAddressAllocationStrategy]; err != nil {
+		return nil, err
+	}
+	return r.getAddressAllocationStrategy(odaName), nil
+}
+
+func (r *Registry) getAddressAllocationStrategy(odaName string) *nddov1.AddressAllocationStrategy {
+	aas, ok := r.strategies[odaName]
+	if !ok {
+		return &nddov1.AddressAllocationStrategy{}
+	}
+	return aas.DeepCopy()
+}
+
+func (r *Registry) GetIDs(ctx context.Context, mg resource.Managed) (registry.IDs, error) {
+	return r.GetIDsByName(ctx, mg.GetNamespace(), getOdaName(mg))
+}
+
+func (r *Registry) GetIDsByName(ctx context.Context, namespace, odaName string) (registry.IDs, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetIDs]; err != nil {
+		return registry.IDs{}, err
+	}
+	ids, ok := r.ids[odaName]
+	if !ok {
+		return registry.IDs{}, fmt.Errorf("no ids for %s", odaName)
+	}
+	return ids, nil
+}
+
+func (r *Registry) GetDerived(ctx context.Context, mg resource.Managed) (map[string]string, error) {
+	return r.GetDerivedByName(ctx, mg.GetNamespace(), getOdaName(mg))
+}
+
+func (r *Registry) GetDerivedByName(ctx context.Context, namespace, odaName string) (map[string]string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetDerived]; err != nil {
+		return nil, err
+	}
+	derived, ok := r.derived[odaName]
+	if !ok {
+		return nil, fmt.Errorf("no derived identifiers for %s", odaName)
+	}
+	d := make(map[string]string, len(derived))
+	for name, value := range derived {
+		d[name] = value
+	}
+	return d, nil
+}
+
+// GetRegistryEndpoint returns the bufconn address every register is served on.
+func (r *Registry) GetRegistryEndpoint(ctx context.Context, registerName string) (string, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetRegistryClient]; err != nil {
+		return "", err
+	}
+	return bufAddress, nil
+}
+
+func (r *Registry) GetRegistryClient(ctx context.Context, registerName string) (resourcepb.ResourceClient, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodGetRegistryClient]; err != nil {
+		return nil, err
+	}
+	l, ok := r.listeners[registerName]
+	if !ok {
+		srv, ok := r.servers[registerName]
+		if !ok {
+			srv = NewResourceServer()
+			r.servers[registerName] = srv
+		}
+		l = newListener(srv)
+		r.listeners[registerName] = l
+	}
+	return l.client(ctx)
+}
+
+// Watch returns a channel that receives the register of the supplied odns
+// name initially and after every SetRegister or SetAddressAllocationStrategy
+// of that name.
+func (r *Registry) Watch(ctx context.Context, name types.NamespacedName, opts ...registry.WatchOption) (<-chan registry.RegisterEvent, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.errs[MethodWatch]; err != nil {
+		return nil, err
+	}
+	w := &watch{
+		name: name,
+		ch:   make(chan registry.RegisterEvent, 1),
+	}
+	ev := r.event(name)
+	if token := registry.NewWatchOptions(opts...).ResumeToken; token == "" || token != ev.ResumeToken {
+		w.ch <- ev
+	}
+	r.watches[w] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		r.m.Lock()
+		defer r.m.Unlock()
+		delete(r.watches, w)
+		close(w.ch)
+	}()
+	return w.ch, nil
+}
+
+// changed notifies the watches of the odns name, the lock must be held.
+func (r *Registry) changed(odaName string) {
+	r.version++
+	for w := range r.watches {
+		if w.name.Name != odaName {
+			continue
+		}
+		select {
+		case <-w.ch:
+		default:
+		}
+		w.ch <- r.event(w.name)
+	}
+}
+
+// event returns the register event of the odns name, the lock must be held.
+func (r *Registry) event(name types.NamespacedName) registry.RegisterEvent {
+	reg, err := r.getRegister(name.Name)
+	return registry.RegisterEvent{
+		Name:                      name,
+		Register:                  reg,
+		AddressAllocationStrategy: r.getAddressAllocationStrategy(name.Name),
+		ResumeToken:               strconv.FormatUint(r.version, 10),
+		Err:                       err,
+	}
+}
+
+// Close stops the in-process grpc servers and closes the client connections.
+func (r *Registry) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for name, l := range r.listeners {
+		l.close()
+		delete(r.listeners, name)
+	}
+	return nil
+}
+
+func getOdaName(mg resource.Managed) string {
+	o := odns.Name2OdnsResource(mg.GetName()).GetOdns()
+	if o == nil {
+		return ""
+	}
+	fullOdaName, _ := o.GetFullOdaName()
+	return fullOdaName
+}
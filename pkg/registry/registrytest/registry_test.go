@@ -0,0 +1,215 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yndd/nddo-grpc/resource/resourcepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetRegisterByName(t *testing.T) {
+	errBoom := errors.New("boom")
+	full := map[string]string{"ipam": "nokia-ipam", "as": "nokia-as", "ni": "nokia-ni"}
+	partial := map[string]string{"ipam": "nokia-ipam"}
+
+	cases := map[string]struct {
+		opts    []Option
+		odaName string
+		want    map[string]string
+		wantErr bool
+	}{
+		"Register": {
+			opts:    []Option{WithRegister("nokia.region1", full)},
+			odaName: "nokia.region1",
+			want:    full,
+		},
+		"NoRegister": {
+			opts:    []Option{WithRegister("nokia.region1", full)},
+			odaName: "nokia.region2",
+			wantErr: true,
+		},
+		"MissingCriticalRegister": {
+			opts:    []Option{WithRegister("nokia", partial)},
+			odaName: "nokia",
+			wantErr: true,
+		},
+		"WithoutValidation": {
+			opts:    []Option{WithRegister("nokia", partial), WithoutValidation()},
+			odaName: "nokia",
+			want:    partial,
+		},
+		"InjectedError": {
+			opts:    []Option{WithRegister("nokia", full), WithError(MethodGetRegister, errBoom)},
+			odaName: "nokia",
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := New(tc.opts...)
+			got, err := r.GetRegisterByName(context.Background(), "default", tc.odaName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetRegisterByName(...): error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GetRegisterByName(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceServer(t *testing.T) {
+	ctx := context.Background()
+	r := New()
+	defer r.Close()
+
+	c, err := r.GetRegistryClient(ctx, "ipam")
+	if err != nil {
+		t.Fatalf("GetRegistryClient(...): %v", err)
+	}
+
+	first, err := c.ResourceRequest(ctx, newRequest("a"))
+	if err != nil {
+		t.Fatalf("ResourceRequest(a): %v", err)
+	}
+	second, err := c.ResourceRequest(ctx, newRequest("b"))
+	if err != nil {
+		t.Fatalf("ResourceRequest(b): %v", err)
+	}
+	again, err := c.ResourceRequest(ctx, newRequest("a"))
+	if err != nil {
+		t.Fatalf("ResourceRequest(a): %v", err)
+	}
+	if got := indexOf(first); got != 0 {
+		t.Errorf("ResourceRequest(a): index %d, want 0", got)
+	}
+	if got := indexOf(second); got != 1 {
+		t.Errorf("ResourceRequest(b): index %d, want 1", got)
+	}
+	if got := indexOf(again); got != 0 {
+		t.Errorf("ResourceRequest(a) again: index %d, want 0", got)
+	}
+
+	get, err := c.ResourceGet(ctx, newRequest("b"))
+	if err != nil {
+		t.Fatalf("ResourceGet(b): %v", err)
+	}
+	if !get.GetReady() || indexOf(get) != 1 {
+		t.Errorf("ResourceGet(b): ready %t index %d, want ready index 1", get.GetReady(), indexOf(get))
+	}
+
+	if _, err := c.ResourceRelease(ctx, newRequest("b")); err != nil {
+		t.Fatalf("ResourceRelease(b): %v", err)
+	}
+	get, err = c.ResourceGet(ctx, newRequest("b"))
+	if err != nil {
+		t.Fatalf("ResourceGet(b): %v", err)
+	}
+	if get.GetReady() {
+		t.Errorf("ResourceGet(b) after release: ready, want not ready")
+	}
+
+	srv := r.ResourceServer("ipam")
+	if got := srv.Allocations(); got != 1 {
+		t.Errorf("Allocations(): %d, want 1", got)
+	}
+	if got := len(srv.Requests()); got != 6 {
+		t.Errorf("Requests(): %d requests, want 6", got)
+	}
+}
+
+func TestResourceServerErrors(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	cases := map[string]struct {
+		opts  []Option
+		setup func(*ResourceServer)
+		code  codes.Code
+	}{
+		"AllocateError": {
+			setup: func(s *ResourceServer) {
+				s.Allocate = func(*resourcepb.Request, uint64) (map[string]*resourcepb.TypedValue, error) {
+					return nil, status.Error(codes.ResourceExhausted, "pool exhausted")
+				}
+			},
+			code: codes.ResourceExhausted,
+		},
+		"MockResourceRequest": {
+			setup: func(s *ResourceServer) {
+				s.MockResourceRequest = func(context.Context, *resourcepb.Request) (*resourcepb.Reply, error) {
+					return nil, status.Error(codes.Unavailable, "registry down")
+				}
+			},
+			code: codes.Unavailable,
+		},
+		"WithResourceServer": {
+			opts: []Option{WithResourceServer("ipam", &ResourceServer{
+				MockResourceRequest: func(context.Context, *resourcepb.Request) (*resourcepb.Reply, error) {
+					return nil, status.Error(codes.PermissionDenied, "denied")
+				},
+			})},
+			code: codes.PermissionDenied,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := New(tc.opts...)
+			defer r.Close()
+			if tc.setup != nil {
+				tc.setup(r.ResourceServer("ipam"))
+			}
+			c, err := r.GetRegistryClient(ctx, "ipam")
+			if err != nil {
+				t.Fatalf("GetRegistryClient(...): %v", err)
+			}
+			_, err = c.ResourceRequest(ctx, newRequest("a"))
+			if got := status.Code(err); got != tc.code {
+				t.Errorf("ResourceRequest(...): code %s, want %s", got, tc.code)
+			}
+		})
+	}
+
+	t.Run("GetRegistryClient", func(t *testing.T) {
+		r := New(WithError(MethodGetRegistryClient, errBoom))
+		defer r.Close()
+		if _, err := r.GetRegistryClient(ctx, "ipam"); !errors.Is(err, errBoom) {
+			t.Errorf("GetRegistryClient(...): %v, want %v", err, errBoom)
+		}
+	})
+}
+
+func newRequest(name string) *resourcepb.Request {
+	return &resourcepb.Request{
+		Namespace:    "default",
+		RegisterName: "nokia-ipam",
+		Kind:         "ipam",
+		Request: &resourcepb.Req{
+			Selector: map[string]string{"name": name},
+		},
+	}
+}
+
+func indexOf(reply *resourcepb.Reply) uint64 {
+	return reply.GetData()["index"].GetUintVal()
+}
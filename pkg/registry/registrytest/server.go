@@ -0,0 +1,204 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrytest
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yndd/nddo-grpc/resource/resourcepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	bufSize    = 1024 * 1024
+	bufAddress = "bufnet"
+)
+
+// An AllocateFn returns the data of a new allocation.
+type AllocateFn func(req *resourcepb.Request, index uint64) (map[string]*resourcepb.TypedValue, error)
+
+// ResourceServer is an in-memory resourcepb.ResourceServer. By default it
+// hands out allocations with an increasing index, keyed by namespace,
+// register, kind and selector. Each rpc can be overridden with a function.
+type ResourceServer struct {
+	resourcepb.UnimplementedResourceServer
+
+	// Allocate returns the data of a new allocation, by default
+	// {"index": <n>}.
+	Allocate AllocateFn
+	// MockResourceGet, MockResourceRequest and MockResourceRelease replace
+	// the default behaviour of the respective rpc when set.
+	MockResourceGet     func(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error)
+	MockResourceRequest func(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error)
+	MockResourceRelease func(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error)
+
+	m           sync.Mutex
+	index       uint64
+	allocations map[string]map[string]*resourcepb.TypedValue
+	requests    []*resourcepb.Request
+}
+
+// NewResourceServer returns an in-memory ResourceServer.
+func NewResourceServer() *ResourceServer {
+	return &ResourceServer{
+		allocations: make(map[string]map[string]*resourcepb.TypedValue),
+	}
+}
+
+// Requests returns all requests the server received in order.
+func (s *ResourceServer) Requests() []*resourcepb.Request {
+	s.m.Lock()
+	defer s.m.Unlock()
+	reqs := make([]*resourcepb.Request, len(s.requests))
+	copy(reqs, s.requests)
+	return reqs
+}
+
+// Allocations returns the number of active allocations.
+func (s *ResourceServer) Allocations() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return len(s.allocations)
+}
+
+func (s *ResourceServer) ResourceGet(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error) {
+	s.record(req)
+	if s.MockResourceGet != nil {
+		return s.MockResourceGet(ctx, req)
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	data, ok := s.allocations[allocationKey(req)]
+	if !ok {
+		return newReply(false, nil), nil
+	}
+	return newReply(true, data), nil
+}
+
+func (s *ResourceServer) ResourceRequest(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error) {
+	s.record(req)
+	if s.MockResourceRequest != nil {
+		return s.MockResourceRequest(ctx, req)
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	key := allocationKey(req)
+	if data, ok := s.allocations[key]; ok {
+		return newReply(true, data), nil
+	}
+	allocate := s.Allocate
+	if allocate == nil {
+		allocate = defaultAllocate
+	}
+	data, err := allocate(req, s.index)
+	if err != nil {
+		return nil, err
+	}
+	s.index++
+	s.allocations[key] = data
+	return newReply(true, data), nil
+}
+
+func (s *ResourceServer) ResourceRelease(ctx context.Context, req *resourcepb.Request) (*resourcepb.Reply, error) {
+	s.record(req)
+	if s.MockResourceRelease != nil {
+		return s.MockResourceRelease(ctx, req)
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.allocations, allocationKey(req))
+	return newReply(true, nil), nil
+}
+
+func (s *ResourceServer) record(req *resourcepb.Request) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.requests = append(s.requests, req)
+}
+
+func defaultAllocate(req *resourcepb.Request, index uint64) (map[string]*resourcepb.TypedValue, error) {
+	return map[string]*resourcepb.TypedValue{
+		"index": {Value: &resourcepb.TypedValue_UintVal{UintVal: index}},
+	}, nil
+}
+
+func allocationKey(req *resourcepb.Request) string {
+	key := []string{req.GetNamespace(), req.GetRegisterName(), req.GetKind()}
+	selector := req.GetRequest().GetSelector()
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key = append(key, k+"="+selector[k])
+	}
+	return strings.Join(key, "/")
+}
+
+func newReply(ready bool, data map[string]*resourcepb.TypedValue) *resourcepb.Reply {
+	return &resourcepb.Reply{
+		Ready:     ready,
+		Timestamp: time.Now().UnixNano(),
+		Data:      data,
+	}
+}
+
+// listener serves a ResourceServer on an in-process bufconn listener.
+type listener struct {
+	lis   *bufconn.Listener
+	srv   *grpc.Server
+	conns []*grpc.ClientConn
+}
+
+func newListener(rs resourcepb.ResourceServer) *listener {
+	l := &listener{
+		lis: bufconn.Listen(bufSize),
+		srv: grpc.NewServer(),
+	}
+	resourcepb.RegisterResourceServer(l.srv, rs)
+	go l.srv.Serve(l.lis) // nolint:errcheck
+	return l
+}
+
+func (l *listener) client(ctx context.Context) (resourcepb.ResourceClient, error) {
+	conn, err := grpc.DialContext(ctx, bufAddress,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	l.conns = append(l.conns, conn)
+	return resourcepb.NewResourceClient(conn), nil
+}
+
+func (l *listener) close() {
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+	l.srv.Stop()
+}
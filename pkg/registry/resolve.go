@@ -0,0 +1,235 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/yndd/ndd-runtime/pkg/logging"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"github.com/yndd/nddr-org-registry/internal/attributes"
+	"github.com/yndd/nddr-org-registry/internal/derived"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// MaxOrganizationID is the highest organization id
+	MaxOrganizationID = 65535
+	// MaxDeploymentID is the highest deployment id of an organization
+	MaxDeploymentID = 65535
+
+	// status reasons of deployments
+	StatusReasonOrganizationNotFound     = "organization not found"
+	StatusReasonAdminStateDisabled       = "admin state disabled"
+	StatusReasonOrganizationRefMismatch  = "organization reference mismatch"
+	StatusReasonOrganizationNamespace    = "organization namespace not allowed"
+	StatusReasonDeploymentClassNotFound  = "deployment class not found"
+	StatusReasonDeploymentKindNotDefined = "deployment kind not defined"
+	StatusReasonAttributesInvalid        = "attributes invalid"
+	StatusReasonDerivedInvalid           = "derived identifiers invalid"
+
+	// errors
+	errOrganizationNotFound  = "organization not found"
+	errOrganizationNamespace = "organization of namespace %s is not allowed, cross namespace organizations are disabled"
+)
+
+// A DeploymentSource provides the objects the state of a deployment is
+// resolved from, the deployment controller reads them from kubernetes and the
+// file backed registry from its directory.
+type DeploymentSource interface {
+	// AllowCrossNamespaceOrganizations returns true when a deployment can
+	// reference an organization of another namespace.
+	AllowCrossNamespaceOrganizations() bool
+	// GetDeploymentClass returns the class of the deployment, nil when the
+	// deployment does not reference one. A missing class is a not found
+	// error.
+	GetDeploymentClass(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dc, error)
+	// GetDeploymentKind returns the definition of the kind of the
+	// deployment, nil for a built in kind without a definition. A missing
+	// definition is a not found error.
+	GetDeploymentKind(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Dkd, error)
+	// GetOrganization returns the organization of the deployment, nil when it
+	// does not exist.
+	GetOrganization(ctx context.Context, cr orgv1alpha1.Dp) (orgv1alpha1.Org, error)
+	// ListRegisterPolicies returns the register policies in the namespace of
+	// the organization.
+	ListRegisterPolicies(ctx context.Context, org orgv1alpha1.Org) ([]orgv1alpha1.Rp, error)
+	// AllocateDeploymentID returns the id of the deployment in its
+	// organization.
+	AllocateDeploymentID(ctx context.Context, org orgv1alpha1.Org, cr orgv1alpha1.Dp) (int64, error)
+}
+
+// ResolveOrganization sets the state of the organization with the allocated
+// id. The returned error reports a register consumers cannot resolve, the
+// organization is up regardless.
+func ResolveOrganization(cr orgv1alpha1.Org, id int64) error {
+	cr.SetStateOrganizationID(id)
+	register := cr.GetRegister()
+	cr.SetStatus("up")
+	cr.SetReason("")
+	cr.SetStateRegister(register)
+	cr.SetStateAddressAllocationStrategy(cr.GetAddressAllocationStrategy())
+	return ValidateRegister(register)
+}
+
+// ValidateOrganizationNamespace returns an error when the organization
+// reference of the deployment points to another namespace and cross namespace
+// organizations are not allowed.
+func ValidateOrganizationNamespace(cr orgv1alpha1.Dp, allowCrossNamespace bool) error {
+	ns := cr.GetOrganizationNamespace()
+	if allowCrossNamespace || ns == "" || ns == cr.GetNamespace() {
+		return nil
+	}
+	return fmt.Errorf(errOrganizationNamespace, ns)
+}
+
+// ResolveDeployment sets the state of the deployment from its organization,
+// class, kind and the register policies of the organization. It returns true
+// when the state depends on an object that is missing or incomplete, the
+// deployment has to be resolved again once it changes.
+func ResolveDeployment(ctx context.Context, log logging.Logger, src DeploymentSource, cr orgv1alpha1.Dp) (bool, error) {
+	if err := cr.ValidateOrganizationRef(); err != nil {
+		resetState(cr, StatusReasonOrganizationRefMismatch)
+		return true, err
+	}
+	if err := ValidateOrganizationNamespace(cr, src.AllowCrossNamespaceOrganizations()); err != nil {
+		resetState(cr, StatusReasonOrganizationNamespace)
+		return true, err
+	}
+
+	// the class has to be resolved first, it provides the admin state
+	class, err := src.GetDeploymentClass(ctx, cr)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return false, err
+		}
+		cr.SetStateDeploymentClass(nil)
+		resetState(cr, StatusReasonDeploymentClassNotFound)
+		return true, err
+	}
+	classRegister := make(map[string]string)
+	var classAddressAllocationStrategy *nddov1.AddressAllocationStrategy
+	if class != nil {
+		cr.SetStateDeploymentClass(class.Resolve())
+		classRegister = class.GetRegister()
+		classAddressAllocationStrategy = class.GetAddressAllocationStrategy()
+	} else {
+		cr.SetStateDeploymentClass(nil)
+	}
+
+	// the kind is resolved after the class, the class can provide the kind
+	kind, err := src.GetDeploymentKind(ctx, cr)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return false, err
+		}
+		cr.SetStateCriticalRegisters(nil)
+		resetState(cr, StatusReasonDeploymentKindNotDefined)
+		return true, err
+	}
+	kindRegister := make(map[string]string)
+	var kindCriticalRegisters []string
+	if kind != nil {
+		kindRegister = kind.GetRegister()
+		kindCriticalRegisters = kind.GetCriticalRegisters()
+	}
+	cr.SetStateCriticalRegisters(kindCriticalRegisters)
+	if kind != nil {
+		if err := attributes.Validate(kind.GetSchema(), cr.GetAttributes()); err != nil {
+			resetState(cr, StatusReasonAttributesInvalid)
+			return true, err
+		}
+	}
+
+	org, err := src.GetOrganization(ctx, cr)
+	if err != nil {
+		return false, err
+	}
+	if org == nil {
+		resetState(cr, StatusReasonOrganizationNotFound)
+		return true, errors.New(errOrganizationNotFound)
+	}
+
+	id, err := src.AllocateDeploymentID(ctx, org, cr)
+	if err != nil {
+		return false, err
+	}
+	// status changes of the organization do not enqueue its deployments
+	missing := org.GetStateOrganizationID() == 0
+	cr.SetStateOrganizationID(org.GetStateOrganizationID())
+	cr.SetStateDeploymentID(id)
+
+	if cr.GetAdminState() == "disable" {
+		resetState(cr, StatusReasonAdminStateDisabled)
+		return missing, nil
+	}
+
+	policies, err := src.ListRegisterPolicies(ctx, org)
+	if err != nil {
+		return missing, err
+	}
+	match, err := MatchRegisterPolicy(policies, org, cr)
+	if err != nil {
+		log.Debug("invalid register policy rule", "error", err)
+	}
+
+	cr.SetStatus("up")
+	cr.SetReason("")
+	// the defaults of the kind take precedence over the organization
+	depRegister := PolicyRegister(DeploymentRegister(org.GetRegister(), kindRegister), match,
+		DeploymentRegister(classRegister, cr.GetRegister()))
+	if err := ValidateRegister(depRegister, kindCriticalRegisters...); err != nil {
+		// the deployment is up, but consumers cannot resolve its register
+		missing = true
+	}
+	cr.SetStateRegister(depRegister)
+	cr.SetStateRegisterPolicy(match.Reference())
+	aas := DeploymentAddressAllocationStrategy(org.GetAddressAllocationStrategy(),
+		ClassAddressAllocationStrategy(classAddressAllocationStrategy, cr.GetAddressAllocationStrategy()))
+	cr.SetStateAddressAllocationStrategy(aas)
+
+	// the identifiers are derived once both ids are allocated
+	var derivedIDs map[string]string
+	if cr.GetStateOrganizationID() != 0 && cr.GetStateDeploymentID() != 0 {
+		data, err := derived.NewData(org, cr, depRegister)
+		if err == nil {
+			derivedIDs, err = derived.Render(org.GetDerivedTemplates(), data)
+		}
+		if err != nil {
+			resetState(cr, StatusReasonDerivedInvalid)
+			return true, err
+		}
+	}
+	cr.SetStateDerived(derivedIDs)
+	return missing, nil
+}
+
+// resetState sets the deployment down for the reason and clears the state
+// consumers resolve, a down deployment has no register. The resolved class
+// and critical registers are left to their resolution, the class provides
+// the admin state and kind, and the ids stay allocated.
+func resetState(cr orgv1alpha1.Dp, reason string) {
+	cr.SetStatus("down")
+	cr.SetReason(reason)
+	cr.SetStateRegister(make(map[string]string))
+	cr.SetStateRegisterPolicy(nil)
+	cr.SetStateAddressAllocationStrategy(nil)
+	cr.SetStateDerived(nil)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateOrganizationNamespace(t *testing.T) {
+	cases := map[string]struct {
+		crossNamespace bool
+		orgNamespace   string
+		wantErr        bool
+	}{
+		"NoReference":               {orgNamespace: ""},
+		"SameNamespace":             {orgNamespace: "default"},
+		"OtherNamespaceDenied":      {orgNamespace: "infra", wantErr: true},
+		"OtherNamespaceAllowed":     {orgNamespace: "infra", crossNamespace: true},
+		"SameNamespaceCrossAllowed": {orgNamespace: "default", crossNamespace: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dep := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"}}
+			if tc.orgNamespace != "" {
+				dep.Spec.Properties.OrganizationRef = &orgv1alpha1.OrganizationReference{Name: "nokia", Namespace: tc.orgNamespace}
+			}
+			err := ValidateOrganizationNamespace(dep, tc.crossNamespace)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateOrganizationNamespace(...): want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResetState(t *testing.T) {
+	dep := &orgv1alpha1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia.dc1"}}
+	if err := dep.InitializeResource(); err != nil {
+		t.Fatal(err)
+	}
+	dep.SetStatus("up")
+	dep.SetStateRegister(map[string]string{"ipam": "nokia-ipam"})
+	dep.SetStateRegisterPolicy(&orgv1alpha1.RegisterPolicyRuleReference{Policy: "regions", Rule: "core"})
+	dep.SetStateAddressAllocationStrategy(&nddov1.AddressAllocationStrategy{})
+	dep.SetStateDerived(map[string]string{"rt-base": "65000:1"})
+	dep.SetStateCriticalRegisters([]string{"vlan"})
+	dep.SetStateOrganizationID(1)
+	dep.SetStateDeploymentID(2)
+
+	resetState(dep, StatusReasonAdminStateDisabled)
+
+	if dep.GetStatus() != "down" || dep.GetReason() != StatusReasonAdminStateDisabled {
+		t.Errorf("resetState(...): status %s reason %s, want down %s", dep.GetStatus(), dep.GetReason(), StatusReasonAdminStateDisabled)
+	}
+	if len(dep.GetStateRegister()) != 0 || dep.GetStateRegisterPolicy() != nil ||
+		dep.GetStateAddressAllocationStrategy() != nil || dep.GetStateDerived() != nil {
+		t.Errorf("resetState(...): resolved state not cleared: %+v", dep.Status)
+	}
+	if len(dep.GetStateCriticalRegisters()) != 1 || dep.GetStateOrganizationID() != 1 || dep.GetStateDeploymentID() != 2 {
+		t.Errorf("resetState(...): critical registers or ids not kept: %+v", dep.Status)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+
+	"github.com/yndd/nddo-grpc/resource/resourcepb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const instrumentationName = "github.com/yndd/nddr-org-registry/pkg/registry"
+
+// startSpan starts a span of a registry method with the global tracer
+// provider, which is a no-op unless the consumer installs one.
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, "Registry."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func nameAttributes(namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+	}
+}
+
+// tracedClient starts a client span for every call to a registry and
+// propagates its trace context in the metadata of the call. The client of
+// nddo-grpc does not take dial options, so the otelgrpc interceptors cannot
+// be installed on its connection.
+type tracedClient struct {
+	resourcepb.ResourceClient
+	address string
+}
+
+func (c tracedClient) ResourceGet(ctx context.Context, in *resourcepb.Request, opts ...grpc.CallOption) (*resourcepb.Reply, error) {
+	ctx, span := c.startSpan(ctx, "ResourceGet")
+	reply, err := c.ResourceClient.ResourceGet(ctx, in, opts...)
+	endSpan(span, err)
+	return reply, err
+}
+
+func (c tracedClient) ResourceRequest(ctx context.Context, in *resourcepb.Request, opts ...grpc.CallOption) (*resourcepb.Reply, error) {
+	ctx, span := c.startSpan(ctx, "ResourceRequest")
+	reply, err := c.ResourceClient.ResourceRequest(ctx, in, opts...)
+	endSpan(span, err)
+	return reply, err
+}
+
+func (c tracedClient) ResourceRelease(ctx context.Context, in *resourcepb.Request, opts ...grpc.CallOption) (*resourcepb.Reply, error) {
+	ctx, span := c.startSpan(ctx, "ResourceRelease")
+	reply, err := c.ResourceClient.ResourceRelease(ctx, in, opts...)
+	endSpan(span, err)
+	return reply, err
+}
+
+// startSpan starts the client span of method and injects it into the
+// outgoing metadata of ctx.
+func (c tracedClient) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "resource.Resource/"+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", "resource.Resource"),
+			attribute.String("rpc.method", method),
+			attribute.String("net.peer.name", c.address),
+		),
+	)
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otelgrpc.Inject(ctx, &md)
+	return metadata.NewOutgoingContext(ctx, md), span
+}
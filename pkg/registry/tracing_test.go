@@ -0,0 +1,106 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yndd/nddo-grpc/resource/resourcepb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// recordingClient records the outgoing metadata of the calls.
+type recordingClient struct {
+	md metadata.MD
+}
+
+func (c *recordingClient) record(ctx context.Context) (*resourcepb.Reply, error) {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	return &resourcepb.Reply{}, nil
+}
+
+func (c *recordingClient) ResourceGet(ctx context.Context, _ *resourcepb.Request, _ ...grpc.CallOption) (*resourcepb.Reply, error) {
+	return c.record(ctx)
+}
+
+func (c *recordingClient) ResourceRequest(ctx context.Context, _ *resourcepb.Request, _ ...grpc.CallOption) (*resourcepb.Reply, error) {
+	return c.record(ctx)
+}
+
+func (c *recordingClient) ResourceRelease(ctx context.Context, _ *resourcepb.Request, _ ...grpc.CallOption) (*resourcepb.Reply, error) {
+	return c.record(ctx)
+}
+
+func TestTracedClient(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	prevTP, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	calls := map[string]func(resourcepb.ResourceClient, context.Context) (*resourcepb.Reply, error){
+		"ResourceGet": func(c resourcepb.ResourceClient, ctx context.Context) (*resourcepb.Reply, error) {
+			return c.ResourceGet(ctx, &resourcepb.Request{})
+		},
+		"ResourceRequest": func(c resourcepb.ResourceClient, ctx context.Context) (*resourcepb.Reply, error) {
+			return c.ResourceRequest(ctx, &resourcepb.Request{})
+		},
+		"ResourceRelease": func(c resourcepb.ResourceClient, ctx context.Context) (*resourcepb.Reply, error) {
+			return c.ResourceRelease(ctx, &resourcepb.Request{})
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			rc := &recordingClient{}
+			c := tracedClient{ResourceClient: rc, address: "registry:9999"}
+
+			// metadata of the caller is kept
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "caller", "test")
+			if _, err := call(c, ctx); err != nil {
+				t.Fatalf("%s(...): unexpected error: %v", name, err)
+			}
+
+			if got := rc.md.Get("caller"); len(got) != 1 || got[0] != "test" {
+				t.Errorf("%s(...): want caller metadata, got %v", name, rc.md)
+			}
+			if got := rc.md.Get("traceparent"); len(got) != 1 {
+				t.Fatalf("%s(...): want traceparent metadata, got %v", name, rc.md)
+			}
+
+			ended := spans.Ended()
+			span := ended[len(ended)-1]
+			if span.Name() != "resource.Resource/"+name || span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("%s(...): want client span resource.Resource/%s, got %s %s", name, name, span.SpanKind(), span.Name())
+			}
+			want := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier{"Traceparent": rc.md.Get("traceparent")})
+			if got := trace.SpanContextFromContext(want); got.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("%s(...): want propagated span %s, got %s", name, span.SpanContext().SpanID(), got.SpanID())
+			}
+		})
+	}
+}
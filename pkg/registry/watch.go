@@ -0,0 +1,285 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/yndd/app-runtime/pkg/odns"
+	nddov1 "github.com/yndd/nddo-runtime/apis/common/v1"
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// errors
+	errNoWatchSource = "registry watch requires informers or a directory"
+)
+
+// A RegisterEvent carries the effective register and address allocation
+// strategy of an organization or deployment after a change.
+type RegisterEvent struct {
+	// Name of the watched organization or deployment, the name is an odns name.
+	Name                      types.NamespacedName
+	Register                  map[string]string
+	AddressAllocationStrategy *nddov1.AddressAllocationStrategy
+	// ResumeToken is the resourceVersion of the organization or deployment
+	// the register was derived from.
+	ResumeToken string
+	// Err is set when the register cannot be resolved, e.g. the organization
+	// or deployment does not exist or a critical register is missing.
+	Err error
+}
+
+func (e RegisterEvent) equal(o RegisterEvent) bool {
+	if (e.Err == nil) != (o.Err == nil) || (e.Err != nil && e.Err.Error() != o.Err.Error()) {
+		return false
+	}
+	return reflect.DeepEqual(e.Register, o.Register) &&
+		reflect.DeepEqual(e.AddressAllocationStrategy, o.AddressAllocationStrategy)
+}
+
+// A WatchOption configures a watch.
+type WatchOption func(*WatchOptions)
+
+// WatchOptions are the options of a watch.
+type WatchOptions struct {
+	ResumeToken string
+}
+
+// NewWatchOptions returns the watch options set by the supplied WatchOptions.
+func NewWatchOptions(opts ...WatchOption) *WatchOptions {
+	o := &WatchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithResumeToken skips the initial event of a watch when the register did
+// not change since the event with the supplied resume token.
+func WithResumeToken(token string) WatchOption {
+	return func(o *WatchOptions) {
+		o.ResumeToken = token
+	}
+}
+
+func (s *registry) WithInformers(i cache.Informers) {
+	s.informers = i
+}
+
+func (r *registry) Watch(ctx context.Context, name types.NamespacedName, opts ...WatchOption) (<-chan RegisterEvent, error) {
+	o := NewWatchOptions(opts...)
+	// the span covers the setup of the watch, not its lifetime
+	sctx, span := startSpan(ctx, "Watch", nameAttributes(name.Namespace, name.Name)...)
+	if err := r.startWatch(sctx); err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	sub := &subscription{
+		name: name,
+		ch:   make(chan RegisterEvent, 1),
+	}
+	ev, organization := r.resolve(sctx, name)
+	endSpan(span, nil)
+	sub.last = ev
+	sub.organization = organization
+	if o.ResumeToken == "" || o.ResumeToken != ev.ResumeToken {
+		sub.ch <- ev
+	}
+	r.watchers.add(sub)
+
+	go func() {
+		<-ctx.Done()
+		r.watchers.remove(sub)
+	}()
+	return sub.ch, nil
+}
+
+// startWatch hooks the registry into the change notifications of its source
+// the first time a watch is started.
+func (r *registry) startWatch(ctx context.Context) error {
+	r.watchers.m.Lock()
+	defer r.watchers.m.Unlock()
+	if r.watchers.started {
+		return nil
+	}
+	switch {
+	case r.files != nil:
+		r.files.subscribe(func() { r.notify("") })
+	case r.informers != nil:
+		for _, obj := range []client.Object{&orgv1alpha1.Organization{}, &orgv1alpha1.Deployment{}} {
+			i, err := r.informers.GetInformer(ctx, obj)
+			if err != nil {
+				return err
+			}
+			i.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) { r.notifyObject(obj) },
+				// the organization reference of a deployment can change
+				UpdateFunc: func(old, obj interface{}) { r.notifyObject(old, obj) },
+				DeleteFunc: func(obj interface{}) { r.notifyObject(obj) },
+			})
+		}
+	default:
+		return errors.New(errNoWatchSource)
+	}
+	r.watchers.started = true
+	return nil
+}
+
+func (r *registry) notifyObject(objs ...interface{}) {
+	organizations := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+			obj = d.Obj
+		}
+		if o, ok := obj.(client.Object); ok {
+			organizations[organizationOf(o)] = struct{}{}
+		}
+	}
+	for organization := range organizations {
+		r.notify(organization)
+	}
+}
+
+// notify re-resolves the watches of the organization and its deployments, or
+// all watches when organization is empty, and sends an event to those whose
+// register changed.
+func (r *registry) notify(organization string) {
+	for _, sub := range r.watchers.list() {
+		if organization != "" && sub.getOrganization() != organization {
+			continue
+		}
+		ev, organization := r.resolve(context.Background(), sub.name)
+		sub.send(ev, organization)
+	}
+}
+
+// resolve returns the current register event of the supplied odns name and
+// the organization the watched object belongs to. The organization of an
+// object that cannot be resolved is the first segment of the odns name.
+func (r *registry) resolve(ctx context.Context, name types.NamespacedName) (RegisterEvent, string) {
+	ev := RegisterEvent{Name: name}
+	organization := odns.Name2Odns(name.Name).GetOrganization()
+	if r.store == nil {
+		ev.Err = errors.New(errNoStore)
+		return ev, organization
+	}
+
+	obj, err := r.getOda(ctx, name.Namespace, name.Name)
+	if err != nil {
+		ev.Err = err
+		return ev, organization
+	}
+	ev.ResumeToken = obj.GetResourceVersion()
+	ev.Register = obj.GetStateRegister()
+	ev.AddressAllocationStrategy = obj.GetStateAddressAllocationStrategy()
+	ev.Err = ValidateRegister(ev.Register, criticalRegisters(obj)...)
+	return ev, organizationOf(obj)
+}
+
+// organizationOf returns the organization of an organization or deployment,
+// a deployment can reference its organization instead of carrying it in its
+// name.
+func organizationOf(o interface{ GetName() string }) string {
+	if dep, ok := o.(orgv1alpha1.Dp); ok {
+		return dep.GetOrganizationName()
+	}
+	return odns.Name2Odns(o.GetName()).GetOrganization()
+}
+
+type watchers struct {
+	m       sync.Mutex
+	started bool
+	subs    map[*subscription]struct{}
+}
+
+func (w *watchers) add(sub *subscription) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.subs == nil {
+		w.subs = make(map[*subscription]struct{})
+	}
+	w.subs[sub] = struct{}{}
+}
+
+func (w *watchers) remove(sub *subscription) {
+	w.m.Lock()
+	delete(w.subs, sub)
+	w.m.Unlock()
+	sub.close()
+}
+
+func (w *watchers) list() []*subscription {
+	w.m.Lock()
+	defer w.m.Unlock()
+	subs := make([]*subscription, 0, len(w.subs))
+	for sub := range w.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// subscription delivers the latest register event of a name; a consumer that
+// falls behind only receives the most recent event.
+type subscription struct {
+	name types.NamespacedName
+
+	m  sync.Mutex
+	ch chan RegisterEvent
+	// organization the watched object belonged to when last resolved
+	organization string
+	last         RegisterEvent
+	closed       bool
+}
+
+func (s *subscription) getOrganization() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.organization
+}
+
+func (s *subscription) send(ev RegisterEvent, organization string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.organization = organization
+	if s.closed || ev.equal(s.last) {
+		return
+	}
+	s.last = ev
+	select {
+	case <-s.ch:
+	default:
+	}
+	s.ch <- ev
+}
+
+func (s *subscription) close() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	orgv1alpha1 "github.com/yndd/nddr-org-registry/apis/org/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+)
+
+// next returns the pending event of the watch, if any. The fake informers
+// notify synchronously.
+func next(ch <-chan RegisterEvent) (RegisterEvent, bool) {
+	select {
+	case ev := <-ch:
+		return ev, true
+	default:
+		return RegisterEvent{}, false
+	}
+}
+
+func TestWatchOrganizationRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := orgv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	informers := &informertest.FakeInformers{Scheme: scheme}
+	depInformer, err := informers.FakeInformerFor(&orgv1alpha1.Deployment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgInformer, err := informers.FakeInformerFor(&orgv1alpha1.Organization{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a deployment with a plain name that references its organization
+	dep := refDeployment("dc1", &orgv1alpha1.OrganizationReference{Name: "nokia"})
+	store := &depStore{deps: []*orgv1alpha1.Deployment{dep}}
+	r := &registry{store: store, informers: informers}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := r.Watch(ctx, types.NamespacedName{Namespace: "default", Name: "nokia.dc1"})
+	if err != nil {
+		t.Fatalf("Watch(...): %v", err)
+	}
+	if ev, ok := next(ch); !ok || ev.Err != nil || ev.Register["ipam"] != "dc1" {
+		t.Fatalf("Watch(...): initial event %+v, want the register of dc1", ev)
+	}
+
+	setIpam := func(ipam string) *orgv1alpha1.Deployment {
+		updated := dep.DeepCopy()
+		updated.SetStateRegister(map[string]string{"ipam": ipam, "as": "dc1", "ni": "dc1"})
+		store.deps = []*orgv1alpha1.Deployment{updated}
+		return updated
+	}
+
+	// a change of the deployment itself
+	depInformer.Update(dep, setIpam("dc1-ipam"))
+	if ev, ok := next(ch); !ok || ev.Register["ipam"] != "dc1-ipam" {
+		t.Errorf("deployment update: event %+v, sent %t, want ipam dc1-ipam", ev, ok)
+	}
+
+	// a change of another organization does not resolve the watch
+	setIpam("acme-ipam")
+	orgInformer.Add(&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme"}})
+	if ev, ok := next(ch); ok {
+		t.Errorf("acme update: unexpected event %+v", ev)
+	}
+
+	// a change of the referenced organization
+	orgInformer.Update(
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}},
+		&orgv1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nokia"}},
+	)
+	if ev, ok := next(ch); !ok || ev.Register["ipam"] != "acme-ipam" {
+		t.Errorf("nokia update: event %+v, sent %t, want ipam acme-ipam", ev, ok)
+	}
+
+	// a deployment that moves to another organization ends the watch of its
+	// previous odns name
+	moved := refDeployment("dc1", &orgv1alpha1.OrganizationReference{Name: "acme"})
+	store.deps = []*orgv1alpha1.Deployment{moved}
+	depInformer.Update(dep, moved)
+	if ev, ok := next(ch); !ok || ev.Err == nil {
+		t.Errorf("organization reference change: event %+v, sent %t, want an error", ev, ok)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 NDD.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registryclient provides a grpc client for the org registry api.
+package registryclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/yndd/nddo-grpc/ndd"
+	"github.com/yndd/nddr-org-registry/pkg/registrypb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// MetadataUsername and MetadataPassword are the grpc metadata keys that
+	// carry the credentials of a request.
+	MetadataUsername = "username"
+	MetadataPassword = "password"
+)
+
+// NewClient returns a client of the registry api served at c.Address.
+func NewClient(ctx context.Context, c *ndd.Config) (registrypb.RegistryClient, error) {
+	conn, err := Dial(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return registrypb.NewRegistryClient(conn), nil
+}
+
+// Dial returns a connection to the registry api served at c.Address.
+func Dial(ctx context.Context, c *ndd.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if c.Insecure {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsConfig, err := newTLS(c)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	)
+	if c.Username != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&loginCredentials{
+			username:   c.Username,
+			password:   c.Password,
+			requireTLS: !c.Insecure,
+		}))
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	return grpc.DialContext(timeoutCtx, c.Address, opts...)
+}
+
+// newTLS sets up a new TLS profile
+func newTLS(c *ndd.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		Renegotiation:      tls.RenegotiateNever,
+		InsecureSkipVerify: c.SkipVerify,
+	}
+	if c.TLSCert != "" && c.TLSKey != "" {
+		certificate, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if c.TLSCA != "" {
+		certPool := x509.NewCertPool()
+		caFile, err := os.ReadFile(c.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+		if ok := certPool.AppendCertsFromPEM(caFile); !ok {
+			return nil, errors.New("failed to append certificate")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	return tlsConfig, nil
+}
+
+// loginCredentials sends the username and password with every request.
+type loginCredentials struct {
+	username   string
+	password   string
+	requireTLS bool
+}
+
+func (c *loginCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		MetadataUsername: c.username,
+		MetadataPassword: c.password,
+	}, nil
+}
+
+func (c *loginCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
@@ -0,0 +1,277 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.20.1
+// source: registry.proto
+
+package registrypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RegistryClient is the client API for Registry service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RegistryClient interface {
+	GetRegister(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterReply, error)
+	GetAddressAllocationStrategy(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AddressAllocationStrategyReply, error)
+	ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsReply, error)
+	GetRegistryEndpoint(ctx context.Context, in *RegistryEndpointRequest, opts ...grpc.CallOption) (*RegistryEndpointReply, error)
+	WatchRegister(ctx context.Context, in *WatchRegisterRequest, opts ...grpc.CallOption) (Registry_WatchRegisterClient, error)
+}
+
+type registryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRegistryClient(cc grpc.ClientConnInterface) RegistryClient {
+	return &registryClient{cc}
+}
+
+func (c *registryClient) GetRegister(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterReply, error) {
+	out := new(RegisterReply)
+	err := c.cc.Invoke(ctx, "/registry.Registry/GetRegister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) GetAddressAllocationStrategy(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AddressAllocationStrategyReply, error) {
+	out := new(AddressAllocationStrategyReply)
+	err := c.cc.Invoke(ctx, "/registry.Registry/GetAddressAllocationStrategy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsReply, error) {
+	out := new(ListDeploymentsReply)
+	err := c.cc.Invoke(ctx, "/registry.Registry/ListDeployments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) GetRegistryEndpoint(ctx context.Context, in *RegistryEndpointRequest, opts ...grpc.CallOption) (*RegistryEndpointReply, error) {
+	out := new(RegistryEndpointReply)
+	err := c.cc.Invoke(ctx, "/registry.Registry/GetRegistryEndpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) WatchRegister(ctx context.Context, in *WatchRegisterRequest, opts ...grpc.CallOption) (Registry_WatchRegisterClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Registry_ServiceDesc.Streams[0], "/registry.Registry/WatchRegister", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryWatchRegisterClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_WatchRegisterClient interface {
+	Recv() (*WatchRegisterReply, error)
+	grpc.ClientStream
+}
+
+type registryWatchRegisterClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryWatchRegisterClient) Recv() (*WatchRegisterReply, error) {
+	m := new(WatchRegisterReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RegistryServer is the server API for Registry service.
+// All implementations must embed UnimplementedRegistryServer
+// for forward compatibility
+type RegistryServer interface {
+	GetRegister(context.Context, *RegisterRequest) (*RegisterReply, error)
+	GetAddressAllocationStrategy(context.Context, *RegisterRequest) (*AddressAllocationStrategyReply, error)
+	ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsReply, error)
+	GetRegistryEndpoint(context.Context, *RegistryEndpointRequest) (*RegistryEndpointReply, error)
+	WatchRegister(*WatchRegisterRequest, Registry_WatchRegisterServer) error
+	mustEmbedUnimplementedRegistryServer()
+}
+
+// UnimplementedRegistryServer must be embedded to have forward compatible implementations.
+type UnimplementedRegistryServer struct {
+}
+
+func (UnimplementedRegistryServer) GetRegister(context.Context, *RegisterRequest) (*RegisterReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRegister not implemented")
+}
+func (UnimplementedRegistryServer) GetAddressAllocationStrategy(context.Context, *RegisterRequest) (*AddressAllocationStrategyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddressAllocationStrategy not implemented")
+}
+func (UnimplementedRegistryServer) ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeployments not implemented")
+}
+func (UnimplementedRegistryServer) GetRegistryEndpoint(context.Context, *RegistryEndpointRequest) (*RegistryEndpointReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRegistryEndpoint not implemented")
+}
+func (UnimplementedRegistryServer) WatchRegister(*WatchRegisterRequest, Registry_WatchRegisterServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRegister not implemented")
+}
+func (UnimplementedRegistryServer) mustEmbedUnimplementedRegistryServer() {}
+
+// UnsafeRegistryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RegistryServer will
+// result in compilation errors.
+type UnsafeRegistryServer interface {
+	mustEmbedUnimplementedRegistryServer()
+}
+
+func RegisterRegistryServer(s grpc.ServiceRegistrar, srv RegistryServer) {
+	s.RegisterService(&Registry_ServiceDesc, srv)
+}
+
+func _Registry_GetRegister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetRegister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/registry.Registry/GetRegister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetRegister(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_GetAddressAllocationStrategy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetAddressAllocationStrategy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/registry.Registry/GetAddressAllocationStrategy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetAddressAllocationStrategy(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_ListDeployments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeploymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).ListDeployments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/registry.Registry/ListDeployments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).ListDeployments(ctx, req.(*ListDeploymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_GetRegistryEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistryEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetRegistryEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/registry.Registry/GetRegistryEndpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetRegistryEndpoint(ctx, req.(*RegistryEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_WatchRegister_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRegisterRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).WatchRegister(m, &registryWatchRegisterServer{stream})
+}
+
+type Registry_WatchRegisterServer interface {
+	Send(*WatchRegisterReply) error
+	grpc.ServerStream
+}
+
+type registryWatchRegisterServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryWatchRegisterServer) Send(m *WatchRegisterReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Registry_ServiceDesc is the grpc.ServiceDesc for Registry service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Registry_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "registry.Registry",
+	HandlerType: (*RegistryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRegister",
+			Handler:    _Registry_GetRegister_Handler,
+		},
+		{
+			MethodName: "GetAddressAllocationStrategy",
+			Handler:    _Registry_GetAddressAllocationStrategy_Handler,
+		},
+		{
+			MethodName: "ListDeployments",
+			Handler:    _Registry_ListDeployments_Handler,
+		},
+		{
+			MethodName: "GetRegistryEndpoint",
+			Handler:    _Registry_GetRegistryEndpoint_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRegister",
+			Handler:       _Registry_WatchRegister_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "registry.proto",
+}